@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/url"
 	"strconv"
+	"sync"
 
 	"github.com/pinpt/agent/v4/sdk"
 )
@@ -25,6 +26,43 @@ type API struct {
 	pipe          sdk.Pipe
 	// process_id - type_name - state_name
 	statusesMap map[string]map[string]map[string]string
+	// process_id - type_name, the types at the top portfolio backlog level
+	epicTypesMap map[string]map[string]bool
+	// project_id-issue_id - parent, the work item parents seen in this export
+	issueParents      map[string]issueParent
+	issueParentsMutex sync.Mutex
+	// lower case reference_name - field, only the fields in the CustomFields option
+	customFieldsMap   map[string]fieldResponse
+	customFieldsMutex sync.Mutex
+	// email user ref_id - sent, the commit users without an azure identity
+	emailUsers      map[string]bool
+	emailUsersMutex sync.Mutex
+	// group_id - members, the reviewer groups expanded so far
+	groupMembers      map[string][]usersResponse
+	groupMembersMutex sync.Mutex
+	// guards the read and update of the pr issues saved in the state
+	pullRequestIssuesMutex sync.Mutex
+	// guards the read and update of the prs to refetch saved in the state
+	pullRequestRefetchMutex sync.Mutex
+	options                 Options
+}
+
+// Options are the optional, user configurable, export settings
+type Options struct {
+	// IncludeAreaPaths only exports the work items under these area paths, all of them if empty
+	IncludeAreaPaths []string
+	// ExcludeAreaPaths skips the work items under these area paths
+	ExcludeAreaPaths []string
+	// IncludeIssueTypes only exports these work item types, all of them if empty
+	IncludeIssueTypes []string
+	// ExcludeIssueTypes skips these work item types, defaults to the test and shared step types if nil
+	ExcludeIssueTypes []string
+	// HistoryAttachments also exports the attachments found in the update history that are no longer attached
+	HistoryAttachments bool
+	// CustomFields are the reference names of the fields, like Custom.Severity, sent as issue custom fields
+	CustomFields []string
+	// AllBranchCommits sends the commits of all the branches, not only the default one
+	AllBranchCommits bool
 }
 
 // New creates a new instance of the api object
@@ -40,9 +78,18 @@ func New(logger sdk.Logger, client sdk.HTTPClient, state sdk.State, pipe sdk.Pip
 		creds:         creds,
 		integrationID: integrationID,
 		statusesMap:   map[string]map[string]map[string]string{},
+		epicTypesMap:  map[string]map[string]bool{},
+		issueParents:  map[string]issueParent{},
+		groupMembers:  map[string][]usersResponse{},
+		emailUsers:    map[string]bool{},
 	}
 }
 
+// SetOptions sets the optional export settings
+func (a *API) SetOptions(options Options) {
+	a.options = options
+}
+
 func ensureParams(p url.Values) url.Values {
 	if p == nil {
 		p = url.Values{}
@@ -53,8 +100,23 @@ func ensureParams(p url.Values) url.Values {
 	return p
 }
 
-func (a *API) paginate(endpoint string, params url.Values, out chan<- objects) error {
+type paginationType int
+
+const (
+	// paginationTypeSkip pages with $top and $skip until a short page is returned
+	paginationTypeSkip paginationType = iota
+	// paginationTypeContinuation follows the x-ms-continuationtoken header until it's no longer returned. The
+	// endpoints paged this way ignore $skip, so there is no falling back to it
+	paginationTypeContinuation
+)
+
+const continuationTokenHeader = "x-ms-continuationtoken"
+
+func (a *API) paginate(endpoint string, ptype paginationType, params url.Values, out chan<- objects) error {
 	defer close(out)
+	if params == nil {
+		params = url.Values{}
+	}
 	pageNum := 0
 	for {
 		var top string
@@ -63,15 +125,15 @@ func (a *API) paginate(endpoint string, params url.Values, out chan<- objects) e
 			params.Set("$top", top)
 		}
 		maxPage, _ := strconv.Atoi(top)
-		if pageNum > 0 {
+		if ptype == paginationTypeSkip && pageNum > 0 {
 			params.Set("$skip", strconv.Itoa(maxPage*pageNum))
 		}
 		var page pageResponse
-		_, err := a.get(endpoint, params, &page)
+		resp, err := a.get(endpoint, params, &page)
 		if err != nil {
 			return err
 		}
-		if page.Count > 0 {
+		if page.Count > 0 || len(page.Value) > 0 || len(page.Comments) > 0 {
 			if len(page.Value) > 0 {
 				out <- page.Value
 			} else if len(page.Comments) > 0 {
@@ -83,7 +145,17 @@ func (a *API) paginate(endpoint string, params url.Values, out chan<- objects) e
 				return errors.New("response is not standard")
 			}
 		}
-		if page.Count < int64(maxPage) {
+		if ptype == paginationTypeContinuation {
+			token := resp.Headers.Get(continuationTokenHeader)
+			if token == "" {
+				// some endpoints, like comments, return the token in the body instead of the header
+				token = page.ContinuationToken
+			}
+			if token == "" {
+				return nil
+			}
+			params.Set("continuationToken", token)
+		} else if page.Count < int64(maxPage) {
 			return nil
 		}
 		pageNum++
@@ -10,6 +10,8 @@ type pageResponse struct {
 	Value objects `json:"value"`
 	// comments don't have a "value" property, oy vey
 	Comments objects `json:"comments"`
+	// only returned by some endpoints when paginating with continuation tokens, see paginate
+	ContinuationToken string `json:"continuationToken"`
 }
 
 type objects []map[string]interface{}
@@ -30,6 +32,7 @@ type workItemResponse struct {
 		// there are more here, fields, self, workItemComments, workItemRevisions, workItemType, and workItemUpdates
 	} `json:"_links"`
 	Fields struct {
+		AreaPath       string        `json:"System.AreaPath"`
 		AssignedTo     usersResponse `json:"System.AssignedTo"`
 		ChangedDate    time.Time     `json:"System.ChangedDate"`
 		CreatedDate    time.Time     `json:"System.CreatedDate"`
@@ -37,6 +40,7 @@ type workItemResponse struct {
 		Description    string        `json:"System.Description"`
 		DueDate        time.Time     `json:"Microsoft.VSTS.Scheduling.DueDate"` // ??
 		IterationPath  string        `json:"System.IterationPath"`
+		Parent         int           `json:"System.Parent"`
 		TeamProject    string        `json:"System.TeamProject"`
 		Priority       int           `json:"Microsoft.VSTS.Common.Priority"`
 		Reason         string        `json:"System.Reason"`
@@ -48,11 +52,46 @@ type workItemResponse struct {
 		Title          string        `json:"System.Title"`
 		WorkItemType   string        `json:"System.WorkItemType"`
 	} `json:"fields"`
-	Relations []struct {
-		Rel string `json:"rel"`
-		URL string `json:"url"`
-	} `json:"relations"`
-	ID  int    `json:"id"`
+	Relations []workItemRelation `json:"relations"`
+	ID        int                `json:"id"`
+	URL       string             `json:"url"`
+	// all the fields, including the custom ones missing from Fields
+	rawFields map[string]interface{}
+}
+
+func (w *workItemResponse) UnmarshalJSON(b []byte) error {
+	type response workItemResponse // no methods, so this doesn't recurse
+	if err := json.Unmarshal(b, (*response)(w)); err != nil {
+		return err
+	}
+	var raw struct {
+		Fields map[string]interface{} `json:"fields"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	w.rawFields = raw.Fields
+	return nil
+}
+
+type fieldResponse struct {
+	IsIdentity    bool   `json:"isIdentity"`
+	IsPicklist    bool   `json:"isPicklist"`
+	Name          string `json:"name"`
+	ReferenceName string `json:"referenceName"`
+	Type          string `json:"type"` // boolean, dateTime, double, integer, string, plainText, html, identity, picklistString...
+}
+
+type workItemRelation struct {
+	Attributes struct {
+		AuthorizedDate      time.Time `json:"authorizedDate"`
+		Comment             string    `json:"comment"`
+		IsLocked            bool      `json:"isLocked"`
+		Name                string    `json:"name"` // the link name or, for attachments, the file name
+		ResourceCreatedDate time.Time `json:"resourceCreatedDate"`
+		ResourceSize        int64     `json:"resourceSize"`
+	} `json:"attributes"`
+	Rel string `json:"rel"`
 	URL string `json:"url"`
 }
 
@@ -97,6 +136,7 @@ type changelogField struct {
 	NewValue   interface{} `json:"newValue"`
 	OldValue   interface{} `json:"oldvalue"`
 	customerID string
+	projectID  string
 	refType    string
 }
 
@@ -106,18 +146,8 @@ type changelogResponse struct {
 	RevisedDate time.Time                 `json:"revisedDate"`
 	URL         string                    `json:"url"`
 	Relations   struct {
-		Added []struct {
-			Attributes struct {
-				Name string `json:"name"`
-			} `json:"attributes"`
-			URL string `json:"url"`
-		} `json:"added"`
-		Removed []struct {
-			Attributes struct {
-				Name string `json:"name"`
-			} `json:"attributes"`
-			URL string `json:"url"`
-		} `json:"removed"`
+		Added   []workItemRelation `json:"added"`
+		Removed []workItemRelation `json:"removed"`
 	} `json:"relations"`
 	RevisedBy usersResponse `json:"revisedBy"`
 }
@@ -144,6 +174,22 @@ type teamsResponse struct {
 	URL         string `json:"url"`
 }
 
+type boardResponseLight struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+type boardResponse struct {
+	boardResponseLight
+	Columns []struct {
+		ColumnType    string            `json:"columnType"` // incoming, inProgress, outgoing
+		ID            string            `json:"id"`
+		Name          string            `json:"name"`
+		StateMappings map[string]string `json:"stateMappings"` // work item type - state
+	} `json:"columns"`
+}
+
 type workItemsResponse struct {
 	AsOf    time.Time `json:"asOf"`
 	Columns []struct {
@@ -212,6 +258,8 @@ type PullRequestResponse struct {
 			Href string `json:"href"`
 		} `json:"web"`
 	} `json:"_links"`
+	AutoCompleteSetBy   usersResponse `json:"autoCompleteSetBy"`
+	ClosedBy            usersResponse `json:"closedBy"`
 	ClosedDate          time.Time     `json:"closedDate"`
 	CodeReviewID        int64         `json:"codeReviewId"`
 	CreatedBy           usersResponse `json:"createdBy"`
@@ -231,31 +279,36 @@ type PullRequestResponse struct {
 		CommidID string `json:"commitId"`
 		URL      string `json:"url"`
 	} `json:"lastMergeTargetCommit"`
-	MergeID       string             `json:"mergeId"`
-	MergeStatus   string             `json:"mergeStatus"`
-	PullRequestID int                `json:"pullRequestId"`
-	Repository    reposResponseLight `json:"repository"`
-	Reviewers     []struct {
-		DisplayName string `json:"displayName"`
-		ID          string `json:"id"`
-		ImageURL    string `json:"imageUrl"`
-		IsFlagged   bool   `json:"isFlagged"`
-		ReviewerURL string `json:"reviewerUrl"`
-		UniqueName  string `json:"uniqueName"`
-		URL         string `json:"url"`
-		Vote        int64  `json:"vote"`
-	} `json:"reviewers"`
-	SourceBranch       string `json:"sourceRefName"`
-	Status             string `json:"status"`
-	SupportsIterations bool   `json:"supportsIterations"`
-	TargetBranch       string `json:"targetRefName"`
-	Title              string `json:"title"`
-	URL                string `json:"url"`
+	MergeID            string             `json:"mergeId"`
+	MergeStatus        string             `json:"mergeStatus"`
+	PullRequestID      int                `json:"pullRequestId"`
+	Repository         reposResponseLight `json:"repository"`
+	Reviewers          []reviewerResponse `json:"reviewers"`
+	SourceBranch       string             `json:"sourceRefName"`
+	Status             string             `json:"status"`
+	SupportsIterations bool               `json:"supportsIterations"` // iterations (pushes) aren't exported, the sdk has no pull request event model
+	TargetBranch       string             `json:"targetRefName"`
+	Title              string             `json:"title"`
+	URL                string             `json:"url"`
 	Labels             []struct {
 		Name string `json:"name"`
 	} `json:"labels"`
 }
 
+type reviewerResponse struct {
+	DisplayName string          `json:"displayName"`
+	ID          string          `json:"id"`
+	ImageURL    string          `json:"imageUrl"`
+	IsContainer bool            `json:"isContainer"` // a group or a team
+	IsFlagged   bool            `json:"isFlagged"`
+	IsRequired  bool            `json:"isRequired"` // not sent, the sdk review and review request have no field for it
+	ReviewerURL string          `json:"reviewerUrl"`
+	UniqueName  string          `json:"uniqueName"`
+	URL         string          `json:"url"`
+	Vote        int64           `json:"vote"`
+	VotedFor    []usersResponse `json:"votedFor"` // the groups this reviewer voted on behalf of
+}
+
 type PullRequestResponseWithShas struct {
 	PullRequestResponse
 	commitSHAs []string
@@ -293,47 +346,39 @@ type commitsResponse struct {
 	} `json:"changeCounts"`
 }
 
-type singleCommitResponse struct {
-	Author struct {
-		Date     time.Time `json:"date"`
-		Email    string    `json:"email"`
-		ImageURL string    `json:"imageUrl"`
-		Name     string    `json:"name"`
-	} `json:"author"`
-	ChangeCounts struct {
-		Add    int64 `json:"Add"`
-		Delete int64 `json:"Delete"`
-		Edit   int64 `json:"Edit"`
-	} `json:"changeCounts"`
-	Comment   string `json:"comment"`
-	Committer struct {
-		Date     time.Time `json:"date"`
-		Email    string    `json:"email"`
-		ImageURL string    `json:"imageUrl"`
-		Name     string    `json:"name"`
-	} `json:"committer"`
-	Push struct {
-		Date     time.Time     `json:"date"`
-		PushedBy usersResponse `json:"pushedBy"`
-	} `json:"push"`
-	RemoteURL string `json:"remoteUrl"`
+type refsResponse struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
 }
+
+type branchStatsResponse struct {
+	AheadCount    int64  `json:"aheadCount"`
+	BehindCount   int64  `json:"behindCount"`
+	IsBaseVersion bool   `json:"isBaseVersion"`
+	Name          string `json:"name"`
+}
+
 type threadsReponse struct {
 	Comments []struct {
 		Author                 usersResponse `json:"author"`
 		CommentType            string        `json:"commentType"`
 		Content                string        `json:"content"`
 		ID                     int64         `json:"id"`
+		IsDeleted              bool          `json:"isDeleted"`
 		LastContentUpdatedDate time.Time     `json:"lastContentUpdatedDate"`
 		LastUpdatedDate        time.Time     `json:"lastUpdatedDate"`
 		ParentCommentID        int64         `json:"parentCommentId"`
 		PublishedDate          time.Time     `json:"publishedDate"`
 	} `json:"comments"`
-	ID              int64                    `json:"id"`
-	Identities      map[string]usersResponse `json:"identities"`
-	IsDeleted       bool                     `json:"isDeleted"`
-	LastUpdatedDate time.Time                `json:"lastUpdatedDate"`
-	PublishedDate   time.Time                `json:"publishedDate"`
+	ID         int64                    `json:"id"`
+	Identities map[string]usersResponse `json:"identities"`
+	IsDeleted  bool                     `json:"isDeleted"`
+	Properties map[string]struct {
+		Type  string      `json:"$type"`
+		Value interface{} `json:"$value"`
+	} `json:"properties"`
+	LastUpdatedDate time.Time `json:"lastUpdatedDate"`
+	PublishedDate   time.Time `json:"publishedDate"`
 }
 
 type webhookPayload struct {
@@ -399,6 +444,12 @@ type processesResponse struct {
 }
 
 type itemTypeResponse struct {
+	Behaviors []struct {
+		Behavior struct {
+			ID string `json:"id"`
+		} `json:"behavior"`
+		IsDefault bool `json:"isDefault"`
+	} `json:"behaviors"`
 	CustomizationType   string `json:"customizationType"`
 	Description         string `json:"description"`
 	IsDefault           bool   `json:"isDefault"`
@@ -409,6 +460,15 @@ type itemTypeResponse struct {
 	TypeID              string `json:"typeId"`
 }
 
+type behaviorResponse struct {
+	Inherits struct {
+		BehaviorRefName string `json:"behaviorRefName"`
+	} `json:"inherits"`
+	Name          string `json:"name"`
+	Rank          int    `json:"rank"`
+	ReferenceName string `json:"referenceName"`
+}
+
 type stateResponse struct {
 	Color             string `json:"color"`
 	CustomizationType string `json:"customizationType"`
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/pinpt/agent/v4/sdk"
+)
+
+// FetchBranches sends the branches of a repo with how far ahead and behind the default branch they are
+func (a *API) FetchBranches(projid string, repoid string, defaultBranch string) error {
+
+	sdk.LogInfo(a.logger, "fetching branches", "project_id", projid, "repo_id", repoid)
+
+	if defaultBranch == "" {
+		// empty repo
+		return nil
+	}
+	endpoint := fmt.Sprintf(`%s/_apis/git/repositories/%s/stats/branches`, url.PathEscape(projid), url.PathEscape(repoid))
+	params := url.Values{}
+	params.Set("baseVersionDescriptor.version", defaultBranch)
+	params.Set("baseVersionDescriptor.versionType", "branch")
+	var out struct {
+		Value []branchStatsResponse `json:"value"`
+	}
+	// not paginated, all the branches are returned
+	if _, err := a.get(endpoint, params, &out); err != nil {
+		return err
+	}
+	for _, b := range out.Value {
+		refid := a.createBranchID(repoid, b.Name)
+		branch := &sdk.SourceCodeBranch{
+			AheadDefaultCount:     b.AheadCount,
+			BehindDefaultCount:    b.BehindCount,
+			CustomerID:            a.customerID,
+			Default:               b.IsBaseVersion,
+			IntegrationInstanceID: &a.integrationID,
+			// nothing left to merge, all the commits are in the default branch
+			Merged:  !b.IsBaseVersion && b.AheadCount == 0,
+			Name:    b.Name,
+			RefID:   refid,
+			RefType: a.refType,
+			RepoID:  sdk.NewSourceCodeRepoID(a.customerID, repoid, a.refType),
+		}
+		if err := a.pipe.Write(branch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
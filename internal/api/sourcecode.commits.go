@@ -0,0 +1,143 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pinpt/agent/v4/sdk"
+)
+
+// fetchBranchRefs gets the branches of a repo, the names are returned without the refs/heads/ prefix
+func (a *API) fetchBranchRefs(projid string, repoid string) ([]refsResponse, error) {
+	endpoint := fmt.Sprintf(`%s/_apis/git/repositories/%s/refs`, url.PathEscape(projid), url.PathEscape(repoid))
+	params := url.Values{}
+	params.Set("filter", "heads/")
+
+	var refs []refsResponse
+	out := make(chan objects)
+	errochan := make(chan error)
+	go func() {
+		for object := range out {
+			var value []refsResponse
+			if err := object.Unmarshal(&value); err != nil {
+				errochan <- err
+				return
+			}
+			for _, ref := range value {
+				ref.Name = strings.TrimPrefix(ref.Name, "refs/heads/")
+				refs = append(refs, ref)
+			}
+		}
+		errochan <- nil
+	}()
+	// ===========================================
+	go func() {
+		err := a.paginate(endpoint, paginationTypeContinuation, params, out)
+		if err != nil {
+			errochan <- err
+		}
+	}()
+	if err := <-errochan; err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// FetchCommits sends the commits of the default branch, or of all the branches if the AllBranchCommits option is set.
+// Only the commits newer than updated are sent, unless it's zero
+func (a *API) FetchCommits(projid string, repoid string, defaultBranch string, updated time.Time) error {
+
+	sdk.LogInfo(a.logger, "fetching commits", "project_id", projid, "repo_id", repoid)
+
+	if defaultBranch == "" {
+		// empty repo
+		return nil
+	}
+	branches := []string{defaultBranch}
+	if a.options.AllBranchCommits {
+		refs, err := a.fetchBranchRefs(projid, repoid)
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			branches = appendUnique(branches, ref.Name)
+		}
+	}
+	// the same commit is in many branches, only send it once
+	sent := map[string]bool{}
+	for _, branch := range branches {
+		if err := a.fetchBranchCommits(projid, repoid, branch, updated, sent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *API) fetchBranchCommits(projid string, repoid string, branch string, updated time.Time, sent map[string]bool) error {
+	endpoint := fmt.Sprintf(`%s/_apis/git/repositories/%s/commits`, url.PathEscape(projid), url.PathEscape(repoid))
+	params := url.Values{}
+	params.Set("$top", "1000")
+	params.Set("searchCriteria.itemVersion.version", branch)
+	if !updated.IsZero() {
+		params.Set("searchCriteria.fromDate", updated.Format(time.RFC3339))
+	}
+
+	out := make(chan objects)
+	errochan := make(chan error)
+	go func() {
+		for object := range out {
+			var value []commitsResponse
+			if err := object.Unmarshal(&value); err != nil {
+				errochan <- err
+				return
+			}
+			for _, c := range value {
+				if sent[c.CommitID] {
+					continue
+				}
+				sent[c.CommitID] = true
+				authorRefID, err := a.commitUserRefID(c.Author.Name, c.Author.Email)
+				if err != nil {
+					errochan <- err
+					return
+				}
+				committerRefID, err := a.commitUserRefID(c.Committer.Name, c.Committer.Email)
+				if err != nil {
+					errochan <- err
+					return
+				}
+				commit := &sdk.SourceCodeCommit{
+					Active:                true,
+					Additions:             c.ChangeCounts.Add,
+					AuthorRefID:           authorRefID,
+					CommitterRefID:        committerRefID,
+					CustomerID:            a.customerID,
+					Deletions:             c.ChangeCounts.Delete,
+					IntegrationInstanceID: &a.integrationID,
+					Message:               c.Comment,
+					RefID:                 c.CommitID,
+					RefType:               a.refType,
+					RepoID:                sdk.NewSourceCodeRepoID(a.customerID, repoid, a.refType),
+					Sha:                   c.CommitID,
+					URL:                   c.RemoteURL,
+				}
+				sdk.ConvertTimeToDateModel(c.Author.Date, &commit.CreatedDate)
+				if err := a.pipe.Write(commit); err != nil {
+					errochan <- err
+					return
+				}
+			}
+		}
+		errochan <- nil
+	}()
+	// ===========================================
+	go func() {
+		err := a.paginate(endpoint, paginationTypeSkip, params, out)
+		if err != nil {
+			errochan <- err
+		}
+	}()
+	return <-errochan
+}
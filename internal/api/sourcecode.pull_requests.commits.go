@@ -7,58 +7,144 @@ import (
 	"github.com/pinpt/agent/v4/sdk"
 )
 
-func (a *API) sendPullRequestCommit(projid string, repoRefID string, p PullRequestResponseWithShas) error {
-	sha := p.commitSHAs[len(p.commitSHAs)-1]
-	endpoint := fmt.Sprintf(`_apis/git/repositories/%s/commits/%s`, url.PathEscape(p.Repository.ID), url.PathEscape(sha))
-	var out singleCommitResponse
-	params := url.Values{}
-	params.Set("changeCount", "1")
+// commitsBatchSize is how many commits are requested in each commitsbatch call
+const commitsBatchSize = 100
+
+// pullRequestCommitsState is saved for every pr sent, its commits only change when the source commit does
+type pullRequestCommitsState struct {
+	SourceCommit string   `json:"source_commit"`
+	SHAs         []string `json:"shas"`
+}
+
+func pullRequestCommitsKey(prrefid string) string {
+	return "pr_commits_" + prrefid
+}
 
-	if _, err := a.get(endpoint, params, &out); err != nil {
+func (a *API) sendPullRequestCommit(projid string, repoRefID string, p PullRequestResponseWithShas, c commitsResponse) error {
+	authorRefID, err := a.commitUserRefID(c.Author.Name, c.Author.Email)
+	if err != nil {
+		return err
+	}
+	committerRefID, err := a.commitUserRefID(c.Committer.Name, c.Committer.Email)
+	if err != nil {
 		return err
 	}
 	prrefid := a.createPullRequestID(projid, repoRefID, p.PullRequestID)
 	commit := &sdk.SourceCodePullRequestCommit{
 		Active:                true,
-		Additions:             out.ChangeCounts.Add,
-		AuthorRefID:           out.Push.PushedBy.ID,
-		BranchID:              sdk.NewSourceCodeBranchID(a.customerID, repoRefID, a.refType, p.SourceBranch, p.commitSHAs[0]),
-		CommitterRefID:        out.Push.PushedBy.ID,
+		Additions:             c.ChangeCounts.Add,
+		AuthorRefID:           authorRefID,
+		BranchID:              a.createBranchID(repoRefID, p.SourceBranch),
+		CommitterRefID:        committerRefID,
 		CustomerID:            a.customerID,
-		Deletions:             out.ChangeCounts.Delete,
+		Deletions:             c.ChangeCounts.Delete,
 		IntegrationInstanceID: &a.integrationID,
-		Message:               out.Comment,
+		Message:               c.Comment,
 		PullRequestID:         sdk.NewSourceCodePullRequestID(a.customerID, prrefid, a.refType, repoRefID),
-		RefID:                 sha,
+		RefID:                 c.CommitID,
 		RefType:               a.refType,
 		RepoID:                sdk.NewSourceCodeRepoID(a.customerID, repoRefID, a.refType),
-		Sha:                   sha,
-		URL:                   out.RemoteURL,
+		Sha:                   c.CommitID,
+		URL:                   c.RemoteURL,
 	}
-	sdk.ConvertTimeToDateModel(out.Push.Date, &commit.CreatedDate)
+	sdk.ConvertTimeToDateModel(c.Author.Date, &commit.CreatedDate)
 	return a.pipe.Write(commit)
 }
 
-func (a *API) sendPullRequestCommits(projid string, reponame string, pr PullRequestResponseWithShas) error {
-	endpoint := fmt.Sprintf(`_apis/git/repositories/%s/pullRequests/%d/commits`, url.PathEscape(pr.Repository.ID), pr.PullRequestID)
+// fetchCommitsBatch gets the commit details, with the change counts, of many commits in one call
+func (a *API) fetchCommitsBatch(repoid string, shas []string) ([]commitsResponse, error) {
+	endpoint := fmt.Sprintf(`_apis/git/repositories/%s/commitsbatch`, url.PathEscape(repoid))
+	var payload struct {
+		IDs []string `json:"ids"`
+		Top int      `json:"$top"`
+	}
+	payload.IDs = shas
+	payload.Top = len(shas)
 	var out struct {
-		Value []commitsResponseLight `json:"value"`
+		Value []commitsResponse `json:"value"`
+	}
+	if _, err := a.post(endpoint, payload, nil, &out); err != nil {
+		return nil, err
 	}
+	return out.Value, nil
+}
+
+// fetchPullRequestCommits gets all the commits of a PR, newest first
+func (a *API) fetchPullRequestCommits(pr PullRequestResponseWithShas) ([]commitsResponseLight, error) {
+	endpoint := fmt.Sprintf(`_apis/git/repositories/%s/pullRequests/%d/commits`, url.PathEscape(pr.Repository.ID), pr.PullRequestID)
 	params := url.Values{}
-	params.Set("$top", "10000") // there is no pagination, so make this number BIG
-	if _, err := a.get(endpoint, params, &out); err != nil {
+	params.Set("$top", "500")
+
+	var commits []commitsResponseLight
+	out := make(chan objects)
+	errochan := make(chan error, 1)
+	go func() {
+		errochan <- a.paginate(endpoint, paginationTypeContinuation, params, out)
+	}()
+	var err error
+	for object := range out {
+		if err != nil {
+			continue // drain the channel so paginate can finish
+		}
+		var value []commitsResponseLight
+		if err = object.Unmarshal(&value); err == nil {
+			commits = append(commits, value...)
+		}
+	}
+	if perr := <-errochan; perr != nil {
+		return nil, perr
+	}
+	return commits, err
+}
+
+func (a *API) sendPullRequestCommits(projid string, reponame string, pr PullRequestResponseWithShas) error {
+	prrefid := a.createPullRequestID(projid, pr.Repository.ID, pr.PullRequestID)
+	key := pullRequestCommitsKey(prrefid)
+	var last pullRequestCommitsState
+	if _, err := a.state.Get(key, &last); err != nil {
 		return err
 	}
-	if len(out.Value) > 0 { // pr without commits? this should never be 0
-		for _, commit := range out.Value {
-			pr.commitSHAs = append(pr.commitSHAs, commit.CommitID)
-			pr := pr
-			if err := a.sendPullRequestCommit(projid, pr.Repository.ID, pr); err != nil {
+	if last.SourceCommit != "" && last.SourceCommit == pr.LastMergeSourceCommit.CommidID {
+		// nothing was pushed since the pr was sent, its commits were already sent too
+		pr.commitSHAs = last.SHAs
+		if err := a.sendPullRequest(projid, reponame, pr.Repository.ID, pr); err != nil {
+			return err
+		}
+		return a.setPullRequestRefetch(pr.Repository.ID, pr.PullRequestID, false)
+	}
+	commits, err := a.fetchPullRequestCommits(pr)
+	if err != nil {
+		// don't send the pr with a truncated list of commits, it would overwrite the one already exported.
+		// Flag it instead, so that it's sent on the next export even if it's older than the last one
+		sdk.LogWarn(a.logger, "error fetching all the commits for PR, sending it on the next export", "pr_ref_id", prrefid, "pr_id", pr.PullRequestID, "repo_id", pr.Repository.ID, "err", err)
+		return a.setPullRequestRefetch(pr.Repository.ID, pr.PullRequestID, true)
+	}
+	if len(commits) == 0 { // pr without commits? this should never be 0
+		return nil
+	}
+	for _, commit := range commits {
+		pr.commitSHAs = append(pr.commitSHAs, commit.CommitID)
+	}
+	for i := 0; i < len(pr.commitSHAs); i += commitsBatchSize {
+		end := i + commitsBatchSize
+		if end > len(pr.commitSHAs) {
+			end = len(pr.commitSHAs)
+		}
+		commits, err := a.fetchCommitsBatch(pr.Repository.ID, pr.commitSHAs[i:end])
+		if err != nil {
+			return err
+		}
+		for _, commit := range commits {
+			if err := a.sendPullRequestCommit(projid, pr.Repository.ID, pr, commit); err != nil {
 				return err
 			}
 		}
-		return a.sendPullRequest(projid, reponame, pr.Repository.ID, pr)
 	}
-
-	return nil
+	if err := a.sendPullRequest(projid, reponame, pr.Repository.ID, pr); err != nil {
+		return err
+	}
+	if err := a.state.Set(key, pullRequestCommitsState{pr.LastMergeSourceCommit.CommidID, pr.commitSHAs}); err != nil {
+		return err
+	}
+	return a.setPullRequestRefetch(pr.Repository.ID, pr.PullRequestID, false)
 }
@@ -9,15 +9,42 @@ import (
 	"github.com/pinpt/agent/v4/sdk"
 )
 
-// FetchPullRequests calls the pull request api and processes the reponse writing each object to the pipeline
+// FetchPullRequests calls the pull request api and processes the reponse writing each object to the pipeline.
+// If updated isn't zero only the open prs and the ones closed after it are sent
 func (a *API) FetchPullRequests(projid string, repoid string, reponame string, updated time.Time) error {
 	sdk.LogInfo(a.logger, "fetching pull requests", "project_id", projid, "repo_id", repoid)
 
+	// the prs flagged before this export, the ones that are still flagged after it are sent again
+	refetch, err := a.fetchPullRequestsToRefetch(repoid)
+	if err != nil {
+		return err
+	}
+	if updated.IsZero() {
+		if err := a.fetchPullRequests(projid, repoid, reponame, "all", time.Time{}); err != nil {
+			return err
+		}
+		return a.refetchPullRequests(projid, repoid, reponame, refetch)
+	}
+	// there is no way to know which open prs changed, send all of them
+	if err := a.fetchPullRequests(projid, repoid, reponame, "active", time.Time{}); err != nil {
+		return err
+	}
+	for _, status := range []string{"completed", "abandoned"} {
+		if err := a.fetchPullRequests(projid, repoid, reponame, status, updated); err != nil {
+			return err
+		}
+	}
+	return a.refetchPullRequests(projid, repoid, reponame, refetch)
+}
+
+// fetchPullRequests sends the prs with this status, only the ones closed after closedAfter if it isn't zero.
+// The prs are listed newest created first, one closed recently can be after many older ones so all of them are listed
+func (a *API) fetchPullRequests(projid string, repoid string, reponame string, status string, closedAfter time.Time) error {
 	endpoint := fmt.Sprintf(`%s/_apis/git/repositories/%s/pullrequests`, url.PathEscape(projid), url.PathEscape(repoid))
 
 	params := url.Values{}
 	params.Set("$top", "1000")
-	params.Set("status", "all")
+	params.Set("status", status)
 	// ===========================================
 	out := make(chan objects, 1)
 	errochan := make(chan error, 1)
@@ -26,8 +53,15 @@ func (a *API) FetchPullRequests(projid string, repoid string, reponame string, u
 			value := []PullRequestResponse{}
 			if err := object.Unmarshal(&value); err != nil {
 				errochan <- err
+				return
+			}
+			var prs []PullRequestResponse
+			for _, p := range value {
+				if closedAfter.IsZero() || p.ClosedDate.After(closedAfter) {
+					prs = append(prs, p)
+				}
 			}
-			err := a.ProcessPullRequests(value, projid, repoid, reponame, updated)
+			err := a.ProcessPullRequests(prs, projid, repoid, reponame)
 			if err != nil {
 				errochan <- err
 				return
@@ -37,7 +71,7 @@ func (a *API) FetchPullRequests(projid string, repoid string, reponame string, u
 	}()
 	// ===========================================
 	func() {
-		if err := a.paginate(endpoint, params, out); err != nil {
+		if err := a.paginate(endpoint, paginationTypeSkip, params, out); err != nil {
 			errochan <- err
 		}
 	}()
@@ -67,36 +101,25 @@ func (a *API) UpdatePullRequest(refid string, v *sdk.SourcecodePullRequestUpdate
 	return nil
 }
 
-func (a *API) ProcessPullRequests(value []PullRequestResponse, projid string, repoid string, reponame string, updated time.Time) error {
-
-	historical := updated.IsZero()
-	var pullrequests []PullRequestResponse
-	var pullrequestcomments []PullRequestResponse
+// ProcessPullRequests sends the prs with their commits, comments and reviews
+func (a *API) ProcessPullRequests(value []PullRequestResponse, projid string, repoid string, reponame string) error {
 
-	for _, p := range value {
+	for i, p := range value {
 		// modify the url to show the ui instead of api call
 		p.URL = strings.ToLower(p.URL)
 		p.URL = strings.Replace(p.URL, "_apis/git/repositories", "_git", 1)
 		p.URL = strings.Replace(p.URL, "/pullrequests/", "/pullrequest/", 1)
-
-		if historical {
-			pullrequests = append(pullrequests, p)
-			pullrequestcomments = append(pullrequestcomments, p)
-		} else if p.Status == "active" || p.CreationDate.After(updated) {
-			//  only fetch the comments if this pr is still opened or was closed after the last processed date
-			pullrequestcomments = append(pullrequestcomments, p)
-		}
-
+		value[i] = p
 	}
 
 	// =================== Commits ===================
 	async := sdk.NewAsync(a.concurrency)
-	for _, p := range pullrequests {
+	for _, p := range value {
 		pr := PullRequestResponseWithShas{}
 		pr.PullRequestResponse = p
 		async.Do(func() error {
-			pr.SourceBranch = strings.TrimPrefix(p.SourceBranch, "refs/heads/")
-			pr.TargetBranch = strings.TrimPrefix(p.TargetBranch, "refs/heads/")
+			pr.SourceBranch = strings.TrimPrefix(pr.SourceBranch, "refs/heads/")
+			pr.TargetBranch = strings.TrimPrefix(pr.TargetBranch, "refs/heads/")
 			if err := a.sendPullRequestCommits(projid, reponame, pr); err != nil {
 				return fmt.Errorf("error fetching commits for PR, skipping pr_id:%v repo_id:%v err:%v", pr.PullRequestID, pr.Repository.ID, err)
 			}
@@ -109,7 +132,7 @@ func (a *API) ProcessPullRequests(value []PullRequestResponse, projid string, re
 
 	// =================== Comments ===================
 	async = sdk.NewAsync(a.concurrency)
-	for _, p := range pullrequestcomments {
+	for _, p := range value {
 		pr := p
 		async.Do(func() error {
 			return a.sendPullRequestComment(projid, repoid, pr)
@@ -122,10 +145,12 @@ func (a *API) sendPullRequest(projid string, reponame string, repoRefID string,
 	prrefid := a.createPullRequestID(projid, repoRefID, p.PullRequestID)
 	pr := &sdk.SourceCodePullRequest{
 		Active:                true,
+		BranchID:              a.createBranchID(repoRefID, p.SourceBranch),
 		BranchName:            p.SourceBranch,
 		CreatedByRefID:        p.CreatedBy.ID,
 		CustomerID:            a.customerID,
 		Description:           `<div class="source-azure">` + p.Description + "</div>",
+		Draft:                 p.IsDraft,
 		IntegrationInstanceID: &a.integrationID,
 		RefID:                 prrefid,
 		RefType:               a.refType,
@@ -135,12 +160,20 @@ func (a *API) sendPullRequest(projid string, reponame string, repoRefID string,
 		CommitShas:            p.commitSHAs,
 		Identifier:            fmt.Sprintf("%s#%d", reponame, p.PullRequestID),
 	}
+	// the merge strategy, auto complete and merge conflicts aren't sent, the sdk pr has no fields for them.
+	// Neither are the pr statuses and branch policy evaluations (build validation, minimum reviewers...),
+	// the sdk has no model for pull request checks
+
 	if p.commitSHAs != nil {
-		pr.BranchID = sdk.NewSourceCodeBranchID(a.customerID, repoRefID, a.refType, p.SourceBranch, p.commitSHAs[0])
 		for _, sha := range p.commitSHAs {
 			pr.CommitIds = append(pr.CommitIds, sdk.NewSourceCodeCommitID(a.customerID, sha, a.refType, repoRefID))
 		}
 	}
+	issueids, err := a.fetchPullRequestIssueIDs(projid, repoRefID, p.PullRequestID)
+	if err != nil {
+		return err
+	}
+	pr.IssueIds = issueids
 	sdk.ConvertTimeToDateModel(p.ClosedDate, &pr.ClosedDate)
 	sdk.ConvertTimeToDateModel(p.CreationDate, &pr.CreatedDate)
 
@@ -149,22 +182,19 @@ func (a *API) sendPullRequest(projid string, reponame string, repoRefID string,
 		pr.Status = sdk.SourceCodePullRequestStatusMerged
 		pr.MergeSha = p.LastMergeCommit.CommidID
 		pr.MergeCommitID = sdk.NewSourceCodeCommitID(a.customerID, pr.MergeSha, a.refType, repoRefID)
-		sdk.ConvertTimeToDateModel(p.CompletionQueueTime, &pr.MergedDate)
+		// the completion queue time is when it was set to complete, the merge happens when it's closed
+		sdk.ConvertTimeToDateModel(p.ClosedDate, &pr.MergedDate)
+		// with auto complete the pr is completed on behalf of whoever set it
+		pr.MergedByRefID = p.ClosedBy.ID
+		if pr.MergedByRefID == "" {
+			pr.MergedByRefID = p.AutoCompleteSetBy.ID
+		}
+		pr.ClosedByRefID = pr.MergedByRefID
 	case "active":
 		pr.Status = sdk.SourceCodePullRequestStatusOpen
 	case "abandoned":
 		pr.Status = sdk.SourceCodePullRequestStatusClosed
-	}
-	for _, r := range p.Reviewers {
-		switch r.Vote {
-		case -10:
-			pr.ClosedByRefID = r.ID
-		case 10:
-			if pr.ClosedByRefID == "" {
-				pr.ClosedByRefID = r.ID
-			}
-			pr.MergedByRefID = r.ID
-		}
+		pr.ClosedByRefID = p.ClosedBy.ID
 	}
 
 	pr.Labels = make([]string, 0)
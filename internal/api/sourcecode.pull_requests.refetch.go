@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/pinpt/agent/v4/sdk"
+)
+
+func pullRequestRefetchKey(repoid string) string {
+	return "pr_refetch_" + repoid
+}
+
+// fetchPullRequestsToRefetch gets the ids of the prs of the repo flagged to be sent again
+func (a *API) fetchPullRequestsToRefetch(repoid string) ([]int, error) {
+	a.pullRequestRefetchMutex.Lock()
+	defer a.pullRequestRefetchMutex.Unlock()
+	var ids []int
+	if _, err := a.state.Get(pullRequestRefetchKey(repoid), &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// setPullRequestRefetch flags a pr to be sent again on the next export, or clears the flag. The flag is kept
+// in the state so that the pr is fetched even if it's older than the last export
+func (a *API) setPullRequestRefetch(repoid string, prid int, refetch bool) error {
+	a.pullRequestRefetchMutex.Lock()
+	defer a.pullRequestRefetchMutex.Unlock()
+	key := pullRequestRefetchKey(repoid)
+	var ids []int
+	if _, err := a.state.Get(key, &ids); err != nil {
+		return err
+	}
+	var res []int
+	for _, id := range ids {
+		if id != prid {
+			res = append(res, id)
+		}
+	}
+	if refetch {
+		res = append(res, prid)
+	}
+	if len(res) == len(ids) {
+		// nothing changed
+		return nil
+	}
+	if len(res) == 0 {
+		return a.state.Delete(key)
+	}
+	return a.state.Set(key, res)
+}
+
+// refetchPullRequests sends the prs in ids that are still flagged, whatever their dates are
+func (a *API) refetchPullRequests(projid string, repoid string, reponame string, ids []int) error {
+	flagged, err := a.fetchPullRequestsToRefetch(repoid)
+	if err != nil {
+		return err
+	}
+	still := map[int]bool{}
+	for _, id := range flagged {
+		still[id] = true
+	}
+	var prs []PullRequestResponse
+	for _, id := range ids {
+		if !still[id] {
+			// sent since it was flagged
+			continue
+		}
+		endpoint := fmt.Sprintf(`%s/_apis/git/repositories/%s/pullrequests/%d`, url.PathEscape(projid), url.PathEscape(repoid), id)
+		var pr PullRequestResponse
+		if _, err := a.get(endpoint, nil, &pr); err != nil {
+			// keep the flag, it'll be tried again on the next export
+			sdk.LogWarn(a.logger, "error fetching PR to send it again, skipping", "pr_id", id, "repo_id", repoid, "err", err)
+			continue
+		}
+		prs = append(prs, pr)
+	}
+	if len(prs) == 0 {
+		return nil
+	}
+	sdk.LogInfo(a.logger, "sending flagged pull requests again", "repo_id", repoid, "count", len(prs))
+	return a.ProcessPullRequests(prs, projid, repoid, reponame)
+}
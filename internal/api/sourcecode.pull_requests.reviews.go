@@ -0,0 +1,266 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pinpt/agent/v4/sdk"
+)
+
+// vote threads are system threads with these properties, unlike the comment content they aren't translated
+const (
+	threadTypeProperty      = "CodeReviewThreadType"
+	threadTypeVoteUpdate    = "VoteUpdate"
+	threadVoteResult        = "CodeReviewVoteResult"
+	threadVotedByIdentityID = "CodeReviewVotedByIdentity"
+
+	threadTypeReviewersUpdate       = "ReviewersUpdate"
+	threadReviewersAddedIdentityID  = "CodeReviewReviewersUpdatedAddedIdentity" // with a number suffix when many were added
+	threadReviewersUpdatedByIdentID = "CodeReviewReviewersUpdatedByIdentity"
+)
+
+func reviewStateFromVote(vote int64) sdk.SourceCodePullRequestReviewState {
+	switch vote {
+	case -10: // rejected
+		return sdk.SourceCodePullRequestReviewStateDismissed
+	case -5: // waiting for author
+		return sdk.SourceCodePullRequestReviewStateChangesRequested
+	case 5: // approved with suggestions
+		return sdk.SourceCodePullRequestReviewStateCommented
+	case 10:
+		return sdk.SourceCodePullRequestReviewStateApproved
+	}
+	return sdk.SourceCodePullRequestReviewStatePending
+}
+
+func threadProperty(thread threadsReponse, name string) string {
+	if prop, ok := thread.Properties[name]; ok && prop.Value != nil {
+		return fmt.Sprint(prop.Value)
+	}
+	return ""
+}
+
+// threadVote returns who voted and the vote of a vote thread, ok is false for the other threads
+func threadVote(thread threadsReponse) (user usersResponse, vote int64, ok bool) {
+	if threadProperty(thread, threadTypeProperty) != threadTypeVoteUpdate || len(thread.Comments) == 0 {
+		return usersResponse{}, 0, false
+	}
+	vote, err := strconv.ParseInt(threadProperty(thread, threadVoteResult), 10, 64)
+	if err != nil {
+		return usersResponse{}, 0, false
+	}
+	user, ok = thread.Identities[threadProperty(thread, threadVotedByIdentityID)]
+	return user, vote, ok
+}
+
+func (a *API) fetchPullRequestReviewers(pr PullRequestResponse) ([]reviewerResponse, error) {
+	endpoint := fmt.Sprintf(`_apis/git/repositories/%s/pullRequests/%d/reviewers`, url.PathEscape(pr.Repository.ID), pr.PullRequestID)
+	var out struct {
+		Value []reviewerResponse `json:"value"`
+	}
+	if _, err := a.get(endpoint, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Value, nil
+}
+
+// fetchGroupMembers gets the members of a reviewer group once, the ones that aren't teams have no members
+func (a *API) fetchGroupMembers(projid string, groupid string) []usersResponse {
+	a.groupMembersMutex.Lock()
+	members, ok := a.groupMembers[groupid]
+	a.groupMembersMutex.Unlock()
+	if ok {
+		return members
+	}
+	// not locked while fetching, at worst a group is fetched more than once by prs processed at the same time
+	members, err := a.fetchUsers(projid, groupid)
+	if err != nil {
+		sdk.LogDebug(a.logger, "error fetching the reviewer group members, only teams have members. Keeping the group as the reviewer", "group_id", groupid, "err", err)
+	}
+	a.groupMembersMutex.Lock()
+	a.groupMembers[groupid] = members
+	a.groupMembersMutex.Unlock()
+	return members
+}
+
+// expandReviewers replaces the group reviewers that nobody voted for with their members, each member only once
+// and as pending since the group vote isn't theirs. Only teams can be expanded, other groups are kept as they are
+func (a *API) expandReviewers(projid string, reviewers []reviewerResponse) []reviewerResponse {
+	var res []reviewerResponse
+	seen := map[string]bool{}
+	votedFor := map[string]bool{}
+	for _, r := range reviewers {
+		for _, g := range r.VotedFor {
+			votedFor[g.ID] = true
+		}
+		if !r.IsContainer {
+			res = append(res, r)
+			seen[r.ID] = true
+		}
+	}
+	for _, r := range reviewers {
+		if !r.IsContainer {
+			continue
+		}
+		if votedFor[r.ID] {
+			// the member who voted is already a reviewer
+			continue
+		}
+		members := a.fetchGroupMembers(projid, r.ID)
+		if len(members) == 0 {
+			res = append(res, r)
+			continue
+		}
+		for _, m := range members {
+			if seen[m.ID] {
+				// also an individual reviewer, or in another group
+				continue
+			}
+			seen[m.ID] = true
+			res = append(res, reviewerResponse{
+				DisplayName: m.DisplayName,
+				ID:          m.ID,
+				IsRequired:  r.IsRequired,
+				UniqueName:  m.UniqueName,
+			})
+		}
+	}
+	return res
+}
+
+// sendPullRequestReviews sends a review for every vote in the threads, and one with the current vote for each
+// reviewer that never voted
+func (a *API) sendPullRequestReviews(projid string, repoRefID string, pr PullRequestResponse, threads []threadsReponse, reviewers []reviewerResponse) error {
+	prrefid := a.createPullRequestID(projid, repoRefID, pr.PullRequestID)
+	prid := sdk.NewSourceCodePullRequestID(a.customerID, prrefid, a.refType, repoRefID)
+	repoid := sdk.NewSourceCodeRepoID(a.customerID, repoRefID, a.refType)
+
+	voted := map[string]bool{}
+	for _, thread := range threads {
+		user, vote, ok := threadVote(thread)
+		if !ok {
+			continue
+		}
+		comment := thread.Comments[0]
+		review := &sdk.SourceCodePullRequestReview{
+			Active:                true,
+			CustomerID:            a.customerID,
+			IntegrationInstanceID: &a.integrationID,
+			PullRequestID:         prid,
+			RefID:                 sdk.Hash(pr.PullRequestID, thread.ID, comment.ID),
+			RefType:               a.refType,
+			RepoID:                repoid,
+			State:                 reviewStateFromVote(vote),
+			URL:                   pr.URL,
+			UserRefID:             user.ID,
+		}
+		sdk.ConvertTimeToDateModel(comment.PublishedDate, &review.CreatedDate)
+		if err := a.pipe.Write(review); err != nil {
+			return err
+		}
+		voted[user.ID] = true
+	}
+
+	for _, r := range a.expandReviewers(projid, reviewers) {
+		if voted[r.ID] {
+			continue
+		}
+		review := &sdk.SourceCodePullRequestReview{
+			Active:                true,
+			CustomerID:            a.customerID,
+			IntegrationInstanceID: &a.integrationID,
+			PullRequestID:         prid,
+			RefID:                 sdk.Hash(pr.PullRequestID, r.ID),
+			RefType:               a.refType,
+			RepoID:                repoid,
+			State:                 reviewStateFromVote(r.Vote),
+			URL:                   pr.URL,
+			UserRefID:             r.ID,
+		}
+		// there is no date for the current vote, the reviewer was added some time after the pr was created
+		sdk.ConvertTimeToDateModel(pr.CreationDate, &review.CreatedDate)
+		if err := a.pipe.Write(review); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendPullRequestReviewRequests sends a review request for every reviewer added in the threads, and one for each
+// of the other reviewers that never voted, those were added when the pr was created
+func (a *API) sendPullRequestReviewRequests(projid string, repoRefID string, pr PullRequestResponse, threads []threadsReponse, reviewers []reviewerResponse) error {
+	prrefid := a.createPullRequestID(projid, repoRefID, pr.PullRequestID)
+	prid := sdk.NewSourceCodePullRequestID(a.customerID, prrefid, a.refType, repoRefID)
+	repoid := sdk.NewSourceCodeRepoID(a.customerID, repoRefID, a.refType)
+
+	current := map[string]bool{}
+	for _, r := range reviewers {
+		current[r.ID] = true
+	}
+	requested := map[string]bool{}
+	selfAdded := map[string]bool{}
+	for _, thread := range threads {
+		if threadProperty(thread, threadTypeProperty) != threadTypeReviewersUpdate {
+			continue
+		}
+		sender := thread.Identities[threadProperty(thread, threadReviewersUpdatedByIdentID)]
+		for name := range thread.Properties {
+			if !strings.HasPrefix(name, threadReviewersAddedIdentityID) {
+				continue
+			}
+			user, ok := thread.Identities[threadProperty(thread, name)]
+			if !ok {
+				continue
+			}
+			if user.ID == sender.ID {
+				// added themselves, usually by voting, nobody requested them
+				selfAdded[user.ID] = true
+				continue
+			}
+			request := &sdk.SourceCodePullRequestReviewRequest{
+				// removed reviewers aren't requested anymore
+				Active:                 current[user.ID],
+				CustomerID:             a.customerID,
+				IntegrationInstanceID:  &a.integrationID,
+				PullRequestID:          prid,
+				RefID:                  sdk.Hash(pr.PullRequestID, thread.ID, user.ID),
+				RefType:                a.refType,
+				RepoID:                 repoid,
+				RequestedReviewerRefID: user.ID,
+				SenderRefID:            sender.ID,
+				URL:                    pr.URL,
+			}
+			sdk.ConvertTimeToDateModel(thread.PublishedDate, &request.CreatedDate)
+			if err := a.pipe.Write(request); err != nil {
+				return err
+			}
+			requested[user.ID] = true
+		}
+	}
+
+	for _, r := range reviewers {
+		if requested[r.ID] || selfAdded[r.ID] || len(r.VotedFor) > 0 {
+			// the others were added when the pr was created, there's no thread for them
+			continue
+		}
+		request := &sdk.SourceCodePullRequestReviewRequest{
+			Active:                 true,
+			CustomerID:             a.customerID,
+			IntegrationInstanceID:  &a.integrationID,
+			PullRequestID:          prid,
+			RefID:                  sdk.Hash(pr.PullRequestID, "request", r.ID),
+			RefType:                a.refType,
+			RepoID:                 repoid,
+			RequestedReviewerRefID: r.ID,
+			SenderRefID:            pr.CreatedBy.ID,
+			URL:                    pr.URL,
+		}
+		sdk.ConvertTimeToDateModel(pr.CreationDate, &request.CreatedDate)
+		if err := a.pipe.Write(request); err != nil {
+			return err
+		}
+	}
+	return nil
+}
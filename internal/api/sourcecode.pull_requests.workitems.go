@@ -0,0 +1,167 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pinpt/agent/v4/sdk"
+)
+
+const (
+	artifactLinkRelation = "ArtifactLink"
+	// the rest of the url is project_id/repo_id/pr_id, url encoded
+	pullRequestArtifactPrefix = "vstfs:///Git/PullRequestId/"
+)
+
+func pullRequestIssuesKey(prrefid string) string {
+	return "pr_issues_" + prrefid
+}
+
+// pullRequestArtifact gets the project, repo and pr ids from a work item artifact link url
+func pullRequestArtifact(u string) (projid string, repoid string, prid int, ok bool) {
+	if !strings.HasPrefix(u, pullRequestArtifactPrefix) {
+		return "", "", 0, false
+	}
+	path, err := url.PathUnescape(strings.TrimPrefix(u, pullRequestArtifactPrefix))
+	if err != nil {
+		return "", "", 0, false
+	}
+	parts := strings.Split(path, "/")
+	if len(parts) != 3 {
+		return "", "", 0, false
+	}
+	if prid, err = strconv.Atoi(parts[2]); err != nil {
+		return "", "", 0, false
+	}
+	return parts[0], parts[1], prid, true
+}
+
+// pullRequestLink is a pr linked from an issue, saved in the state of the issue to know which prs it
+// doesn't link to anymore
+type pullRequestLink struct {
+	ProjectID     string `json:"project_id"`
+	RepoID        string `json:"repo_id"`
+	PullRequestID int    `json:"pr_id"`
+}
+
+func issuePullRequestsKey(issueRefID string) string {
+	return "issue_prs_" + issueRefID
+}
+
+// setPullRequestIssues saves the issue in the state of every pr it links to, and removes it from the ones it
+// doesn't link to anymore, so that the prs sent afterwards have the right issues. The prs whose issues changed
+// are flagged to be sent again
+func (a *API) setPullRequestIssues(issueRefID string, relations []workItemRelation) error {
+	var links []pullRequestLink
+	current := map[pullRequestLink]bool{}
+	for _, rel := range relations {
+		if rel.Rel != artifactLinkRelation {
+			continue
+		}
+		projid, repoid, prid, ok := pullRequestArtifact(rel.URL)
+		if !ok {
+			continue
+		}
+		link := pullRequestLink{projid, repoid, prid}
+		if !current[link] {
+			current[link] = true
+			links = append(links, link)
+		}
+	}
+	a.pullRequestIssuesMutex.Lock()
+	defer a.pullRequestIssuesMutex.Unlock()
+	key := issuePullRequestsKey(issueRefID)
+	var previous []pullRequestLink
+	if _, err := a.state.Get(key, &previous); err != nil {
+		return err
+	}
+	linked := map[pullRequestLink]bool{}
+	for _, link := range previous {
+		linked[link] = true
+		if current[link] {
+			continue
+		}
+		if err := a.setPullRequestIssue(link, issueRefID, false); err != nil {
+			return err
+		}
+	}
+	for _, link := range links {
+		if linked[link] {
+			continue
+		}
+		if err := a.setPullRequestIssue(link, issueRefID, true); err != nil {
+			return err
+		}
+	}
+	if len(links) == 0 {
+		if len(previous) == 0 {
+			return nil
+		}
+		return a.state.Delete(key)
+	}
+	return a.state.Set(key, links)
+}
+
+// setPullRequestIssue adds or removes the issue from the state of the pr and flags the pr to be sent again
+func (a *API) setPullRequestIssue(link pullRequestLink, issueRefID string, linked bool) error {
+	key := pullRequestIssuesKey(a.createPullRequestID(link.ProjectID, link.RepoID, link.PullRequestID))
+	var refids []string
+	if _, err := a.state.Get(key, &refids); err != nil {
+		return err
+	}
+	var res []string
+	for _, refid := range refids {
+		if refid != issueRefID {
+			res = append(res, refid)
+		}
+	}
+	if linked {
+		res = append(res, issueRefID)
+	}
+	var err error
+	if len(res) == 0 {
+		err = a.state.Delete(key)
+	} else {
+		err = a.state.Set(key, res)
+	}
+	if err != nil {
+		return err
+	}
+	return a.setPullRequestRefetch(link.RepoID, link.PullRequestID, true)
+}
+
+// fetchPullRequestIssueIDs gets the issues linked to a pr, and the ones linking to it found when exporting the issues.
+// The linked work items are assumed to be in the same project as the pr
+func (a *API) fetchPullRequestIssueIDs(projid string, repoRefID string, prid int) ([]string, error) {
+	endpoint := fmt.Sprintf(`%s/_apis/git/repositories/%s/pullRequests/%d/workitems`, url.PathEscape(projid), url.PathEscape(repoRefID), prid)
+	var out struct {
+		Value []struct {
+			ID string `json:"id"`
+		} `json:"value"`
+	}
+	if _, err := a.get(endpoint, nil, &out); err != nil {
+		return nil, err
+	}
+	var refids []string
+	for _, item := range out.Value {
+		id, err := strconv.Atoi(item.ID)
+		if err != nil {
+			continue
+		}
+		refids = appendUnique(refids, a.createIssueID(projid, id))
+	}
+	var linked []string
+	if _, err := a.state.Get(pullRequestIssuesKey(a.createPullRequestID(projid, repoRefID, prid)), &linked); err != nil {
+		return nil, err
+	}
+	for _, refid := range linked {
+		refids = appendUnique(refids, refid)
+	}
+	ids := make([]string, 0, len(refids))
+	for _, refid := range refids {
+		ids = append(ids, sdk.NewWorkIssueID(a.customerID, refid, a.refType))
+	}
+	return ids, nil
+}
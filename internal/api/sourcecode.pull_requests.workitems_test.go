@@ -0,0 +1,55 @@
+package api
+
+import "testing"
+
+func TestPullRequestArtifact(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		projid string
+		repoid string
+		prid   int
+		ok     bool
+	}{
+		{
+			name:   "encoded",
+			url:    "vstfs:///Git/PullRequestId/proj-1%2Frepo-2%2F31",
+			projid: "proj-1",
+			repoid: "repo-2",
+			prid:   31,
+			ok:     true,
+		},
+		{
+			name:   "not encoded",
+			url:    "vstfs:///Git/PullRequestId/proj-1/repo-2/31",
+			projid: "proj-1",
+			repoid: "repo-2",
+			prid:   31,
+			ok:     true,
+		},
+		{
+			name: "commit link",
+			url:  "vstfs:///Git/Commit/proj-1%2Frepo-2%2Fabcdef",
+		},
+		{
+			name: "missing pr id",
+			url:  "vstfs:///Git/PullRequestId/proj-1%2Frepo-2",
+		},
+		{
+			name: "pr id not a number",
+			url:  "vstfs:///Git/PullRequestId/proj-1%2Frepo-2%2Fabc",
+		},
+		{
+			name: "bad encoding",
+			url:  "vstfs:///Git/PullRequestId/proj-1%2Frepo-2%2F3%",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projid, repoid, prid, ok := pullRequestArtifact(tt.url)
+			if projid != tt.projid || repoid != tt.repoid || prid != tt.prid || ok != tt.ok {
+				t.Errorf("got %q %q %d %v, want %q %q %d %v", projid, repoid, prid, ok, tt.projid, tt.repoid, tt.prid, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/pinpt/agent/v4/sdk"
+)
+
+func userEmailKey(email string) string {
+	return "user_email_" + strings.ToLower(email)
+}
+
+// setUserEmail saves the user id by email so that commit authors can be matched to their azure identity,
+// the unique name is the email for Azure AD and Microsoft accounts
+func (a *API) setUserEmail(u usersResponse) error {
+	if !strings.Contains(u.UniqueName, "@") {
+		return nil
+	}
+	return a.state.Set(userEmailKey(u.UniqueName), u.ID)
+}
+
+// commitUserRefID returns the azure identity of a commit author or committer if their email matches a user,
+// otherwise a ref_id based on the email. The email users are sent the first time they are seen
+func (a *API) commitUserRefID(name string, email string) (string, error) {
+	if email == "" {
+		return "", nil
+	}
+	var refid string
+	if ok, err := a.state.Get(userEmailKey(email), &refid); err != nil {
+		return "", err
+	} else if ok {
+		return refid, nil
+	}
+	refid = sdk.Hash(strings.ToLower(email))
+	a.emailUsersMutex.Lock()
+	if a.emailUsers[refid] {
+		a.emailUsersMutex.Unlock()
+		return refid, nil
+	}
+	a.emailUsers[refid] = true
+	a.emailUsersMutex.Unlock()
+	err := a.pipe.Write(&sdk.SourceCodeUser{
+		CustomerID:            a.customerID,
+		Email:                 sdk.StringPointer(email),
+		IntegrationInstanceID: &a.integrationID,
+		Name:                  name,
+		RefID:                 refid,
+		RefType:               a.refType,
+		Type:                  sdk.SourceCodeUserTypeHuman,
+	})
+	return refid, err
+}
@@ -2,13 +2,14 @@ package api
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/pinpt/agent/v4/sdk"
 )
 
-func stringEquals(str string, vals ...string) bool {
+func stringEqualsFold(str string, vals ...string) bool {
 	for _, v := range vals {
-		if str == v {
+		if strings.EqualFold(str, v) {
 			return true
 		}
 	}
@@ -26,8 +27,13 @@ type pullRequestRepoProjectRefs struct {
 	PullRequestID int    `json:"pull_request_id"`
 }
 
+// issueRefID is the ref_id of a work item, azure ids are only unique per project
+func issueRefID(customerID string, projid string, issueid int) string {
+	return sdk.Hash(customerID, projid, issueid)
+}
+
 func (a *API) createIssueID(projid string, issueid int) string {
-	newrefid := sdk.Hash(a.customerID, projid, issueid)
+	newrefid := issueRefID(a.customerID, projid, issueid)
 	if !a.state.Exists(newrefid) {
 		a.state.Set(newrefid, issueProjectRefs{issueid, projid})
 	}
@@ -68,6 +74,12 @@ func (a *API) FetchIssueProjectRefs(hashed string) (projectID string, issueID in
 	return "", 0, errors.New("project id and issue id not found")
 }
 
+// createBranchID is the id of a branch in a repo. It's keyed on the name, the first sha is left empty, so that
+// it doesn't change with every push to the branch
+func (a *API) createBranchID(repoRefID string, name string) string {
+	return sdk.NewSourceCodeBranchID(a.customerID, repoRefID, a.refType, name, "")
+}
+
 func appendUnique(slice []string, word string) []string {
 	for _, w := range slice {
 		if w == word {
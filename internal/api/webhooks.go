@@ -29,39 +29,23 @@ func (a *API) RemoveAllWebHooks() error {
 	params.Set("consumerId", "webHooks")
 	params.Set("consumerActionId", "httpRequest")
 
-	out := make(chan objects)
-	errochan := make(chan error)
-	go func() {
-		for object := range out {
-			var value []webhookPayload
-			if err := object.Unmarshal(&value); err != nil {
-				errochan <- err
-				return
-			}
-			async := sdk.NewAsync(int(a.concurrency))
-			for _, res := range value {
-				if strings.Contains(res.ConsumerInputs.URL, "pinpoint.com/hook") {
-					id := res.ID
-					async.Do(func() error {
-						return a.DeleteWebhooks([]string{id})
-					})
-				}
-			}
-			if err := async.Wait(); err != nil {
-				errochan <- err
-				return
-			}
-		}
-		errochan <- nil
-	}()
-	// ===========================================
-	go func() {
-		err := a.paginate(endpoint, params, out)
-		if err != nil {
-			errochan <- err
+	// the subscriptions aren't paged, all of them are returned at once
+	var out struct {
+		Value []webhookPayload `json:"value"`
+	}
+	if _, err := a.get(endpoint, params, &out); err != nil {
+		return err
+	}
+	async := sdk.NewAsync(int(a.concurrency))
+	for _, res := range out.Value {
+		if strings.Contains(res.ConsumerInputs.URL, "pinpoint.com/hook") {
+			id := res.ID
+			async.Do(func() error {
+				return a.DeleteWebhooks([]string{id})
+			})
 		}
-	}()
-	return <-errochan
+	}
+	return async.Wait()
 }
 
 // CreateWebhook creates webhook
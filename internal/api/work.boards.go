@@ -0,0 +1,76 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/pinpt/agent/v4/sdk"
+)
+
+// FetchBoards sends the kanban boards of each team, one per backlog level. The teams are team_id - name
+func (a *API) FetchBoards(projid string, teams map[string]string) error {
+
+	sdk.LogInfo(a.logger, "fetching boards", "project_id", projid)
+
+	process := a.statusesMap[projectProcessMap[projid]]
+	for teamid, teamname := range teams {
+		endpoint := fmt.Sprintf("%s/_apis/work/boards", url.PathEscape(teamid))
+		var out struct {
+			Value []boardResponseLight `json:"value"`
+		}
+		if _, err := a.get(sdk.JoinURL(projid, endpoint), nil, &out); err != nil {
+			return err
+		}
+		for _, b := range out.Value {
+			var board boardResponse
+			if _, err := a.get(sdk.JoinURL(projid, endpoint, url.PathEscape(b.ID)), nil, &board); err != nil {
+				return err
+			}
+			name := teamname + " " + board.Name
+			var boardColumns []sdk.AgileBoardColumns
+			var kanbanColumns []sdk.AgileKanbanColumns
+			for _, col := range board.Columns {
+				var statusids []string
+				for itemtype, state := range col.StateMappings {
+					if id, ok := process[itemtype][state]; ok {
+						statusids = appendUnique(statusids, sdk.NewWorkIssueStatusID(a.customerID, a.refType, id))
+					}
+				}
+				boardColumns = append(boardColumns, sdk.AgileBoardColumns{
+					Name:      col.Name,
+					StatusIds: statusids,
+				})
+				kanbanColumns = append(kanbanColumns, sdk.AgileKanbanColumns{
+					Name:      col.Name,
+					StatusIds: statusids,
+				})
+			}
+			if err := a.pipe.Write(&sdk.AgileBoard{
+				Active:                true,
+				Columns:               boardColumns,
+				CustomerID:            a.customerID,
+				IntegrationInstanceID: &a.integrationID,
+				Name:                  name,
+				RefID:                 board.ID,
+				RefType:               a.refType,
+				Type:                  sdk.AgileBoardTypeKanban,
+			}); err != nil {
+				return err
+			}
+			if err := a.pipe.Write(&sdk.AgileKanban{
+				Active:                true,
+				BoardID:               sdk.NewAgileBoardID(a.customerID, board.ID, a.refType),
+				Columns:               kanbanColumns,
+				CustomerID:            a.customerID,
+				IntegrationInstanceID: &a.integrationID,
+				Name:                  name,
+				ProjectIds:            []string{sdk.NewWorkProjectID(a.customerID, projid, a.refType)},
+				RefID:                 board.ID,
+				RefType:               a.refType,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/pinpt/agent/v4/sdk"
+)
+
+const attachmentRelation = "AttachedFile"
+
+// historyAttachment is an attachment added in the update history, whoever made the update is the uploader
+type historyAttachment struct {
+	relation  workItemRelation
+	userRefID string
+	date      time.Time
+}
+
+// attachmentRefID gets the attachment guid from the url, it's the same in the relations and in the update history
+func attachmentRefID(url string) string {
+	return filepath.Base(url)
+}
+
+// collectHistoryAttachments adds the attachments added in the updates to the found map
+func collectHistoryAttachments(updates []changelogResponse, found map[string]historyAttachment) {
+	for _, update := range updates {
+		for _, rel := range update.Relations.Added {
+			if rel.Rel != attachmentRelation {
+				continue
+			}
+			date := update.RevisedDate
+			if field, ok := update.Fields["System.ChangedDate"]; ok {
+				if changed, err := time.Parse(time.RFC3339, changelogToString(field.NewValue)); err == nil {
+					date = changed
+				}
+			}
+			found[attachmentRefID(rel.URL)] = historyAttachment{rel, update.RevisedBy.ID, date}
+		}
+	}
+}
+
+func newWorkIssueAttachment(rel workItemRelation, userRefID string, date time.Time) sdk.WorkIssueAttachments {
+	attachment := sdk.WorkIssueAttachments{
+		Name:      rel.Attributes.Name,
+		RefID:     attachmentRefID(rel.URL),
+		Size:      rel.Attributes.ResourceSize,
+		URL:       rel.URL,
+		UserRefID: userRefID,
+	}
+	sdk.ConvertTimeToDateModel(date, &attachment.CreatedDate)
+	return attachment
+}
+
+// processAttachments returns the current attachments of the work item, the uploader comes from the update history.
+// If the HistoryAttachments option is set, the ones that were removed since are included too
+func (a *API) processAttachments(relations []workItemRelation, history map[string]historyAttachment) []sdk.WorkIssueAttachments {
+	var attachments []sdk.WorkIssueAttachments
+	current := map[string]bool{}
+	for _, rel := range relations {
+		if rel.Rel != attachmentRelation {
+			continue
+		}
+		refid := attachmentRefID(rel.URL)
+		current[refid] = true
+		date := rel.Attributes.AuthorizedDate
+		if date.IsZero() {
+			date = rel.Attributes.ResourceCreatedDate
+		}
+		var userRefID string
+		if h, ok := history[refid]; ok {
+			userRefID = h.userRefID
+			date = h.date
+		}
+		attachments = append(attachments, newWorkIssueAttachment(rel, userRefID, date))
+	}
+	if a.options.HistoryAttachments {
+		for refid, h := range history {
+			if !current[refid] {
+				attachments = append(attachments, newWorkIssueAttachment(h.relation, h.userRefID, h.date))
+			}
+		}
+	}
+	return attachments
+}
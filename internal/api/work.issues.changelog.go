@@ -6,13 +6,14 @@ import (
 	"net/url"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/pinpt/agent/v4/sdk"
 )
 
-func (a *API) fetchChangeLog(itemtype, projid string, issueid int) ([]sdk.WorkIssueChangeLog, time.Time, error) {
+func (a *API) fetchChangeLog(itemtype, projid string, issueid int) ([]sdk.WorkIssueChangeLog, time.Time, map[string]historyAttachment, error) {
 
 	params := url.Values{}
 	params.Set("$top", "200")
@@ -20,6 +21,7 @@ func (a *API) fetchChangeLog(itemtype, projid string, issueid int) ([]sdk.WorkIs
 
 	var changelogs []sdk.WorkIssueChangeLog
 	var latestChange time.Time
+	attachments := map[string]historyAttachment{}
 
 	out := make(chan objects)
 	errochan := make(chan error)
@@ -30,19 +32,21 @@ func (a *API) fetchChangeLog(itemtype, projid string, issueid int) ([]sdk.WorkIs
 				errochan <- err
 				return
 			}
-			changelogs = append(changelogs, a.processChangelogs(value)...)
+			changelogs = append(changelogs, a.processChangelogs(projid, value)...)
+			collectHistoryAttachments(value, attachments)
 		}
 		errochan <- nil
 	}()
 	// ===========================================
 	go func() {
-		err := a.paginate(sdk.JoinURL(projid, endpoint), params, out)
+		// the updates api has no continuation token, only $top and $skip
+		err := a.paginate(sdk.JoinURL(projid, endpoint), paginationTypeSkip, params, out)
 		if err != nil {
 			errochan <- err
 		}
 	}()
 	if err := <-errochan; err != nil {
-		return nil, time.Time{}, err
+		return nil, time.Time{}, nil, err
 	}
 	sort.Slice(changelogs, func(i int, j int) bool {
 		return changelogs[i].CreatedDate.Epoch < changelogs[j].CreatedDate.Epoch
@@ -51,10 +55,10 @@ func (a *API) fetchChangeLog(itemtype, projid string, issueid int) ([]sdk.WorkIs
 		last := changelogs[len(changelogs)-1]
 		latestChange = sdk.DateFromEpoch(last.CreatedDate.Epoch)
 	}
-	return changelogs, latestChange, nil
+	return changelogs, latestChange, attachments, nil
 }
 
-func (a *API) processChangelogs(response []changelogResponse) []sdk.WorkIssueChangeLog {
+func (a *API) processChangelogs(projid string, response []changelogResponse) []sdk.WorkIssueChangeLog {
 	var changelogs []sdk.WorkIssueChangeLog
 	previousState := ""
 	for i, changelog := range response {
@@ -71,6 +75,7 @@ func (a *API) processChangelogs(response []changelogResponse) []sdk.WorkIssueCha
 					continue
 				}
 				values.customerID = a.customerID
+				values.projectID = projid
 				values.refType = a.refType
 				name, from, to := extractor(values)
 				if from == "" && to == "" {
@@ -168,6 +173,15 @@ func changeLogExtractCreatedDate(changelog changelogResponse) sdk.WorkIssueChang
 	return createdDate
 }
 
+// issueID converts a raw work item id into the same issue id FetchIssues uses, empty if there is no id
+func (item changelogField) issueID(value interface{}) string {
+	id, err := strconv.Atoi(changelogToString(value))
+	if err != nil {
+		return ""
+	}
+	return sdk.NewWorkIssueID(item.customerID, issueRefID(item.customerID, item.projectID, id), item.refType)
+}
+
 type changeLogFieldExtractor func(item changelogField) (name sdk.WorkIssueChangeLogField, from string, to string)
 
 func extractUsers(item changelogField) (from string, to string) {
@@ -251,10 +265,10 @@ var changelogFields = map[string]changeLogFieldExtractor{
 		return sdk.WorkIssueChangeLogFieldSprintIds, oldvalue, newvalue
 	},
 	"parent": func(item changelogField) (sdk.WorkIssueChangeLogField, string, string) {
-		oldvalue := sdk.NewWorkIssueID(item.customerID, changelogToString(item.OldValue), item.refType)
-		newvalue := sdk.NewWorkIssueID(item.customerID, changelogToString(item.NewValue), item.refType)
-		return sdk.WorkIssueChangeLogFieldParentID, oldvalue, newvalue
+		return sdk.WorkIssueChangeLogFieldParentID, item.issueID(item.OldValue), item.issueID(item.NewValue)
 	},
+	// System.BoardColumn, System.BoardColumnDone and System.BoardLane are not tracked, WorkIssueChangeLogField
+	// has no board column or swimlane values to send them as. Add them here once the sdk supports them
 	// "Epic Link": func(item work.IssueChangeLog) (string, interface{}, interface{}) {
 	// 	var from, to string
 	// 	if item.From != "" {
@@ -47,7 +47,7 @@ func (a *API) fetchComments(projid string, issueid int) error {
 	}()
 	// ===========================================
 	go func() {
-		err := a.paginate(sdk.JoinURL(projid, endpoint), params, out)
+		err := a.paginate(sdk.JoinURL(projid, endpoint), paginationTypeContinuation, params, out)
 		if err != nil {
 			errochan <- err
 		}
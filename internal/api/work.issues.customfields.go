@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pinpt/agent/v4/sdk"
+)
+
+// fetchCustomFields gets the definitions of the fields in the CustomFields option, once. Fields are defined
+// for the whole organization, so there's no need to do this per project
+func (a *API) fetchCustomFields() (map[string]fieldResponse, error) {
+	a.customFieldsMutex.Lock()
+	defer a.customFieldsMutex.Unlock()
+	if a.customFieldsMap != nil || len(a.options.CustomFields) == 0 {
+		return a.customFieldsMap, nil
+	}
+	var out struct {
+		Value []fieldResponse `json:"value"`
+	}
+	if _, err := a.get("_apis/wit/fields", nil, &out); err != nil {
+		return nil, err
+	}
+	// keyed by the lower case reference name, the names in the option don't need to match its case
+	fields := map[string]fieldResponse{}
+	for _, f := range out.Value {
+		if stringEqualsFold(f.ReferenceName, a.options.CustomFields...) {
+			fields[strings.ToLower(f.ReferenceName)] = f
+		}
+	}
+	for _, name := range a.options.CustomFields {
+		if _, ok := fields[strings.ToLower(name)]; !ok {
+			sdk.LogWarn(a.logger, "custom field not found, skipping", "field", name)
+		}
+	}
+	a.customFieldsMap = fields
+	return fields, nil
+}
+
+// customFieldValue coerces the raw json value to a string based on the field type, identities are
+// converted to their id like the assignee and dates are normalized to RFC3339
+func customFieldValue(field fieldResponse, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	switch {
+	case field.IsIdentity || field.Type == "identity":
+		b, err := json.Marshal(value)
+		if err != nil {
+			return ""
+		}
+		var user usersResponse
+		if err := json.Unmarshal(b, &user); err != nil || user.ID == "" {
+			// older apis return the identity as "Display Name <domain\user>"
+			return changelogToString(value)
+		}
+		return user.ID
+	case field.Type == "dateTime":
+		date, err := time.Parse(time.RFC3339, changelogToString(value))
+		if err != nil {
+			return ""
+		}
+		return date.UTC().Format(time.RFC3339)
+	case field.Type == "integer" || field.Type == "double" || field.Type == "picklistInteger" || field.Type == "picklistDouble":
+		if f, ok := value.(float64); ok {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	case field.Type == "boolean":
+		if b, ok := value.(bool); ok {
+			return strconv.FormatBool(b)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// processCustomFields sets the custom fields found in the work item. Their history isn't sent in the
+// changelog, WorkIssueChangeLogField doesn't have a value for custom fields
+func (a *API) processCustomFields(rawFields map[string]interface{}, issue *sdk.WorkIssue) error {
+	fields, err := a.fetchCustomFields()
+	if err != nil {
+		return err
+	}
+	// in the order of the option so the issue doesn't change between exports
+	sent := map[string]bool{}
+	for _, name := range a.options.CustomFields {
+		field, ok := fields[strings.ToLower(name)]
+		if !ok || sent[field.ReferenceName] {
+			continue
+		}
+		value, ok := rawFields[field.ReferenceName]
+		if !ok {
+			continue
+		}
+		sent[field.ReferenceName] = true
+		issue.CustomFields = append(issue.CustomFields, sdk.WorkIssueCustomFields{
+			ID:    field.ReferenceName,
+			Name:  field.Name,
+			Value: customFieldValue(field, value),
+		})
+	}
+	return nil
+}
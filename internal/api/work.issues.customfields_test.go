@@ -0,0 +1,30 @@
+package api
+
+import "testing"
+
+func TestCustomFieldValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		field fieldResponse
+		value interface{}
+		want  string
+	}{
+		{"nil", fieldResponse{Type: "string"}, nil, ""},
+		{"string", fieldResponse{Type: "string"}, "high", "high"},
+		{"integer", fieldResponse{Type: "integer"}, float64(3), "3"},
+		{"double", fieldResponse{Type: "double"}, 2.5, "2.5"},
+		{"picklist integer", fieldResponse{Type: "picklistInteger"}, float64(10), "10"},
+		{"boolean", fieldResponse{Type: "boolean"}, true, "true"},
+		{"date", fieldResponse{Type: "dateTime"}, "2020-03-04T05:06:07+02:00", "2020-03-04T03:06:07Z"},
+		{"bad date", fieldResponse{Type: "dateTime"}, "yesterday", ""},
+		{"identity", fieldResponse{Type: "identity", IsIdentity: true}, map[string]interface{}{"id": "abc", "displayName": "Jane"}, "abc"},
+		{"identity as text", fieldResponse{Type: "string", IsIdentity: true}, `Jane <corp\jane>`, `Jane <corp\jane>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := customFieldValue(tt.field, tt.value); got != tt.want {
+				t.Errorf("customFieldValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/pinpt/agent/v4/sdk"
+)
+
+const portfolioBehavior = "System.PortfolioBacklogBehavior"
+
+// maxEpicDepth stops the parent walk in case of a very deep, or circular, hierarchy
+const maxEpicDepth = 10
+
+// fetchEpicBehaviors returns the behaviors of the top portfolio backlog level of the process, Epics in the
+// default processes. Each portfolio level is a behavior that inherits from the portfolio behavior, ranked
+func (a *API) fetchEpicBehaviors(processid string) (map[string]bool, error) {
+	params := url.Values{}
+	params.Set("api-version", "5.1-preview.2")
+	var out struct {
+		Value []behaviorResponse `json:"value"`
+	}
+	if _, err := a.get("_apis/work/processes/"+processid+"/behaviors", params, &out); err != nil {
+		return nil, err
+	}
+	res := map[string]bool{}
+	toprank := -1
+	for _, b := range out.Value {
+		if b.Inherits.BehaviorRefName != portfolioBehavior {
+			continue
+		}
+		if b.Rank > toprank {
+			toprank = b.Rank
+			res = map[string]bool{}
+		}
+		if b.Rank == toprank {
+			res[b.ReferenceName] = true
+		}
+	}
+	return res, nil
+}
+
+// epicTypes returns the work item type names that belong to any of the epic behaviors
+func epicTypes(behaviors map[string]bool, types []itemTypeResponse) map[string]bool {
+	res := map[string]bool{}
+	for _, t := range types {
+		for _, b := range t.Behaviors {
+			if behaviors[b.Behavior.ID] {
+				res[t.Name] = true
+			}
+		}
+	}
+	return res
+}
+
+func (a *API) isEpicType(processid string, itemtype string) bool {
+	if types, ok := a.epicTypesMap[processid]; ok && len(types) > 0 {
+		return types[itemtype]
+	}
+	// the behaviors couldn't be fetched, like in older TFS versions, go with the default name
+	return itemtype == "Epic"
+}
+
+type issueParent struct {
+	itemtype string
+	parentid int
+}
+
+func issueParentKey(projid string, issueid int) string {
+	return fmt.Sprintf("%s-%d", projid, issueid)
+}
+
+func (a *API) setIssueParent(projid string, issueid int, itemtype string, parentid int) {
+	a.issueParentsMutex.Lock()
+	a.issueParents[issueParentKey(projid, issueid)] = issueParent{itemtype, parentid}
+	a.issueParentsMutex.Unlock()
+}
+
+func (a *API) fetchIssueParent(projid string, issueid int) (issueParent, error) {
+	a.issueParentsMutex.Lock()
+	parent, ok := a.issueParents[issueParentKey(projid, issueid)]
+	a.issueParentsMutex.Unlock()
+	if ok {
+		return parent, nil
+	}
+	params := url.Values{}
+	params.Set("fields", "System.WorkItemType,System.Parent")
+	endpoint := fmt.Sprintf("_apis/wit/workitems/%d", issueid)
+	var out workItemResponse
+	if _, err := a.get(endpoint, params, &out); err != nil {
+		return issueParent{}, err
+	}
+	a.setIssueParent(projid, issueid, out.Fields.WorkItemType, out.Fields.Parent)
+	return issueParent{out.Fields.WorkItemType, out.Fields.Parent}, nil
+}
+
+// fetchEpicID walks up the parent chain and returns the id of the first epic found, 0 if there is none
+func (a *API) fetchEpicID(projid string, processid string, parentid int) int {
+	id := parentid
+	for depth := 0; id != 0 && depth < maxEpicDepth; depth++ {
+		parent, err := a.fetchIssueParent(projid, id)
+		if err != nil {
+			// the parent could've been deleted or be in a project we can't see
+			sdk.LogWarn(a.logger, "error fetching parent work item, skipping epic", "project_id", projid, "id", id, "err", err)
+			return 0
+		}
+		if a.isEpicType(processid, parent.itemtype) {
+			return id
+		}
+		id = parent.parentid
+	}
+	return 0
+}
@@ -38,8 +38,15 @@ func (a *API) FetchStatuses() error {
 
 		async.Do(func() error {
 
+			epicBehaviors, err := a.fetchEpicBehaviors(val.TypeID)
+			if err != nil {
+				// without the behaviors the epics are found by the type name, look at isEpicType
+				sdk.LogWarn(a.logger, "error fetching process behaviors, using the default epic type", "process_id", val.TypeID, "err", err)
+			}
+
 			params := url.Values{}
 			params.Set("api-version", "5.1-preview.2")
+			params.Set("$expand", "behaviors")
 			var out struct {
 				Value []itemTypeResponse `json:"value"`
 			}
@@ -47,6 +54,10 @@ func (a *API) FetchStatuses() error {
 				return err
 			}
 
+			mu.Lock()
+			a.epicTypesMap[val.TypeID] = epicTypes(epicBehaviors, out.Value)
+			mu.Unlock()
+
 			async2 := sdk.NewAsync(a.concurrency / 2)
 			for _, _v := range out.Value {
 				v := _v
@@ -113,42 +124,69 @@ func (a *API) FetchStatuses() error {
 	return err
 }
 
+// wiqlMaxResults is the most work items a single WIQL query will return, anything past it is silently dropped by azure
+const wiqlMaxResults = 20000
+
 // FetchAllIssues gets issues from project id
 func (a *API) FetchAllIssues(projid string, updated time.Time) error {
 
 	sdk.LogInfo(a.logger, "fetching issues for project", "project_id", projid)
 
+	// walk the id space in windows so that we never hit the WIQL result cap
+	var lastID int64
+	for {
+		ids, err := a.fetchIssueIDs(projid, updated, lastID)
+		if err != nil {
+			return err
+		}
+		var items []string
+		for i, id := range ids {
+			if i != 0 && (i%200) == 0 {
+				err := a.FetchIssues(projid, items)
+				if err != nil {
+					return err
+				}
+				items = []string{}
+			}
+			items = append(items, fmt.Sprint(id))
+		}
+		if err := a.FetchIssues(projid, items); err != nil {
+			return err
+		}
+		if len(ids) < wiqlMaxResults {
+			return nil
+		}
+		lastID = ids[len(ids)-1]
+		sdk.LogDebug(a.logger, "fetching next window of issues", "project_id", projid, "after_id", lastID)
+	}
+}
+
+// fetchIssueIDs returns at most wiqlMaxResults work item ids greater than afterID, in ascending order
+func (a *API) fetchIssueIDs(projid string, updated time.Time, afterID int64) ([]int64, error) {
 	var q struct {
 		Query string `json:"query"`
 	}
-	q.Query = `Select [System.ID], [System.Title] From WorkItems ORDER BY System.ChangedDate Desc` // get newest first
-	if !updated.IsZero() {
-		q.Query += fmt.Sprintf(` WHERE System.ChangedDate > '%s'`, updated.Format(whereDateFormat))
-	}
+	q.Query = newWiqlQuery("[System.Id]").
+		project().
+		areaPaths(a.options.IncludeAreaPaths, a.options.ExcludeAreaPaths).
+		types(a.options.IncludeIssueTypes, a.excludedIssueTypes()).
+		idAfter(afterID).
+		changedAfter(updated).
+		order("[System.Id]", false).
+		String()
 	params := url.Values{}
 	params.Set("timePrecision", "true")
+	params.Set("$top", fmt.Sprint(wiqlMaxResults))
 
 	var out workItemsResponse
 	if _, err := a.post(sdk.JoinURL(projid, "_apis/wit/wiql"), q, params, &out); err != nil {
-		return nil
+		return nil, fmt.Errorf("error querying work items. err: %v", err)
 	}
-
-	var items []string
-	for i, item := range out.WorkItems {
-		if i != 0 && (i%200) == 0 {
-			err := a.FetchIssues(projid, items)
-			if err != nil {
-				return err
-			}
-			items = []string{}
-		}
-		items = append(items, fmt.Sprint(item.ID))
-	}
-	err := a.FetchIssues(projid, items)
-	if err != nil {
-		return err
+	ids := make([]int64, 0, len(out.WorkItems))
+	for _, item := range out.WorkItems {
+		ids = append(ids, item.ID)
 	}
-	return nil
+	return ids, nil
 }
 
 // FetchIssues gets all the issues from the ids array
@@ -181,23 +219,20 @@ func (a *API) FetchIssues(projid string, ids []string) error {
 	if err != nil {
 		return err
 	}
+	// cache the parents of this batch, most of the epic lookups will find them here
+	for _, item := range out.Value {
+		a.setIssueParent(projid, item.ID, item.Fields.WorkItemType, item.Fields.Parent)
+	}
 	async := sdk.NewAsync(a.concurrency)
 	for _, itm := range out.Value {
 		// copy the value to a new variable so that it's inside this scope
 		item := itm
+		if !a.includeIssue(item.Fields.WorkItemType, item.Fields.AreaPath) {
+			continue
+		}
 		async.Do(func() error {
 
 			fields := item.Fields
-			// skip these
-			if stringEquals(fields.WorkItemType,
-				"Microsoft.VSTS.WorkItemTypes.SharedParameter", "SharedParameter", "Shared Parameter",
-				"Microsoft.VSTS.WorkItemTypes.SharedStep", "SharedStep", "Shared Step",
-				"Microsoft.VSTS.WorkItemTypes.TestCase", "TestCase", "Test Case",
-				"Microsoft.VSTS.WorkItemTypes.TestPlan", "TestPlan", "Test Plan",
-				"Microsoft.VSTS.WorkItemTypes.TestSuite", "TestSuite", "Test Suite",
-			) {
-				return nil
-			}
 
 			// if this ticket ticket type does NOT have a resolution "allowed value" but it has a
 			// completed state, make the reason the resolution - I know, confusion
@@ -231,13 +266,25 @@ func (a *API) FetchIssues(projid string, ids []string) error {
 				URL:                   item.Links.HTML.HREF,
 				SprintIds:             []string{sdk.NewAgileSprintID(a.customerID, fields.IterationPath, a.refType)},
 			}
+			a.processRelations(projid, fields.TeamProject, item.Relations, issue)
+			if err := a.setPullRequestIssues(issue.RefID, item.Relations); err != nil {
+				return err
+			}
+			if err := a.processCustomFields(item.rawFields, issue); err != nil {
+				return err
+			}
+			if epicid := a.fetchEpicID(projid, processid, fields.Parent); epicid != 0 {
+				issue.EpicID = sdk.StringPointer(sdk.NewWorkIssueID(a.customerID, a.createIssueID(projid, epicid), a.refType))
+			}
 			sdk.ConvertTimeToDateModel(fields.CreatedDate, &issue.CreatedDate)
 			sdk.ConvertTimeToDateModel(fields.DueDate, &issue.DueDate)
 
 			var updatedDate time.Time
-			if issue.ChangeLog, updatedDate, err = a.fetchChangeLog(fields.WorkItemType, projid, item.ID); err != nil {
+			var attachments map[string]historyAttachment
+			if issue.ChangeLog, updatedDate, attachments, err = a.fetchChangeLog(fields.WorkItemType, projid, item.ID); err != nil {
 				return err
 			}
+			issue.Attachments = a.processAttachments(item.Relations, attachments)
 			// this should only happen if the changelog is empty, which should only happen when an issue is created and not modified,
 			if updatedDate.IsZero() {
 				updatedDate = fields.ChangedDate
@@ -256,6 +303,47 @@ func (a *API) FetchIssues(projid string, ids []string) error {
 	return nil
 }
 
+// defaultExcludedIssueTypes are skipped unless the exclude_issue_types option is set
+var defaultExcludedIssueTypes = []string{
+	"Shared Parameter",
+	"Shared Steps",
+	"Test Case",
+	"Test Plan",
+	"Test Suite",
+}
+
+func (a *API) excludedIssueTypes() []string {
+	if a.options.ExcludeIssueTypes == nil {
+		return defaultExcludedIssueTypes
+	}
+	return a.options.ExcludeIssueTypes
+}
+
+// includeIssue checks the work item against the type and area path options, the same filters are
+// applied in the WIQL query but FetchIssues is also called directly with ids from the webhooks
+func (a *API) includeIssue(itemtype string, areapath string) bool {
+	if len(a.options.IncludeIssueTypes) > 0 && !stringEqualsFold(itemtype, a.options.IncludeIssueTypes...) {
+		return false
+	}
+	if stringEqualsFold(itemtype, a.excludedIssueTypes()...) {
+		return false
+	}
+	if len(a.options.IncludeAreaPaths) > 0 && !areaPathUnder(areapath, a.options.IncludeAreaPaths...) {
+		return false
+	}
+	return !areaPathUnder(areapath, a.options.ExcludeAreaPaths...)
+}
+
+// areaPathUnder mimics the WIQL Under operator, a path matches itself and all its children
+func areaPathUnder(path string, parents ...string) bool {
+	for _, parent := range parents {
+		if strings.EqualFold(path, parent) || strings.HasPrefix(strings.ToLower(path), strings.ToLower(parent)+`\`) {
+			return true
+		}
+	}
+	return false
+}
+
 var hasResolutions = map[string]bool{}
 var hasResolutionsMutex sync.Mutex
 
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pinpt/agent/v4/sdk"
+)
+
+const parentRelation = "System.LinkTypes.Hierarchy-Reverse"
+
+type linkedIssueType struct {
+	linkType sdk.WorkIssueLinkedIssuesLinkType
+	reverse  bool
+}
+
+// relation name - link type. Children (Hierarchy-Forward) are skipped, they point back to this item as their parent
+var linkedIssueRelations = map[string]linkedIssueType{
+	"System.LinkTypes.Related":              {sdk.WorkIssueLinkedIssuesLinkTypeRelates, false},
+	"System.LinkTypes.Duplicate-Reverse":    {sdk.WorkIssueLinkedIssuesLinkTypeDuplicates, false}, // Duplicate Of
+	"System.LinkTypes.Duplicate-Forward":    {sdk.WorkIssueLinkedIssuesLinkTypeDuplicates, true},  // Duplicate
+	"System.LinkTypes.Dependency-Forward":   {sdk.WorkIssueLinkedIssuesLinkTypeBlocks, false},     // Successor
+	"System.LinkTypes.Dependency-Reverse":   {sdk.WorkIssueLinkedIssuesLinkTypeBlocks, true},      // Predecessor
+	"Microsoft.VSTS.Common.Affects-Forward": {sdk.WorkIssueLinkedIssuesLinkTypeCauses, false},     // Affects
+	"Microsoft.VSTS.Common.Affects-Reverse": {sdk.WorkIssueLinkedIssuesLinkTypeCauses, true},      // Affected By
+}
+
+// relationItemID gets the work item id from the relation url
+func relationItemID(url string) (int, bool) {
+	id, err := strconv.Atoi(filepath.Base(url))
+	return id, err == nil
+}
+
+// processRelations sets the parent and the linked issues. The relation urls don't have the project, so
+// the related items are assumed to be in the same project as the issue
+func (a *API) processRelations(projid string, teamproject string, relations []workItemRelation, issue *sdk.WorkIssue) {
+	for _, rel := range relations {
+		if rel.Rel == parentRelation {
+			if id, ok := relationItemID(rel.URL); ok {
+				issue.ParentID = sdk.NewWorkIssueID(a.customerID, a.createIssueID(projid, id), a.refType)
+			}
+			continue
+		}
+		link, ok := linkedIssueRelations[rel.Rel]
+		if !ok {
+			continue
+		}
+		id, ok := relationItemID(rel.URL)
+		if !ok {
+			continue
+		}
+		refid := a.createIssueID(projid, id)
+		issue.LinkedIssues = append(issue.LinkedIssues, sdk.WorkIssueLinkedIssues{
+			IssueID:          sdk.NewWorkIssueID(a.customerID, refid, a.refType),
+			IssueIdentifier:  fmt.Sprintf("%s-%d", teamproject, id),
+			IssueRefID:       refid,
+			LinkType:         link.linkType,
+			ReverseDirection: link.reverse,
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// wiqlQuery builds a flat WIQL query, all the conditions are joined with And
+type wiqlQuery struct {
+	fields     []string
+	conditions []string
+	orderBy    []string
+}
+
+func newWiqlQuery(fields ...string) *wiqlQuery {
+	return &wiqlQuery{fields: fields}
+}
+
+// wiqlQuote quotes a string literal, single quotes are escaped by doubling them
+func wiqlQuote(val string) string {
+	return "'" + strings.ReplaceAll(val, "'", "''") + "'"
+}
+
+func wiqlQuoteList(vals []string) string {
+	quoted := make([]string, 0, len(vals))
+	for _, v := range vals {
+		quoted = append(quoted, wiqlQuote(v))
+	}
+	return "(" + strings.Join(quoted, ", ") + ")"
+}
+
+// where adds a raw condition to the query
+func (q *wiqlQuery) where(condition string) *wiqlQuery {
+	q.conditions = append(q.conditions, condition)
+	return q
+}
+
+// project limits the results to the project the query is posted to
+func (q *wiqlQuery) project() *wiqlQuery {
+	return q.where(`[System.TeamProject] = @project`)
+}
+
+func (q *wiqlQuery) idAfter(id int64) *wiqlQuery {
+	return q.where(fmt.Sprintf(`[System.Id] > %d`, id))
+}
+
+// changedAfter is a noop if the date is zero
+func (q *wiqlQuery) changedAfter(date time.Time) *wiqlQuery {
+	if date.IsZero() {
+		return q
+	}
+	return q.where(fmt.Sprintf(`[System.ChangedDate] > '%s'`, date.Format(whereDateFormat)))
+}
+
+// areaPaths includes the items under any of the include paths and drops the ones under any of the exclude paths
+func (q *wiqlQuery) areaPaths(include []string, exclude []string) *wiqlQuery {
+	if len(include) > 0 {
+		var conds []string
+		for _, path := range include {
+			conds = append(conds, `[System.AreaPath] Under `+wiqlQuote(path))
+		}
+		q.where("(" + strings.Join(conds, " Or ") + ")")
+	}
+	for _, path := range exclude {
+		q.where(`[System.AreaPath] Not Under ` + wiqlQuote(path))
+	}
+	return q
+}
+
+// types includes only the include work item types, if any, and drops the exclude types
+func (q *wiqlQuery) types(include []string, exclude []string) *wiqlQuery {
+	if len(include) > 0 {
+		q.where(`[System.WorkItemType] In ` + wiqlQuoteList(include))
+	}
+	if len(exclude) > 0 {
+		q.where(`[System.WorkItemType] Not In ` + wiqlQuoteList(exclude))
+	}
+	return q
+}
+
+func (q *wiqlQuery) order(field string, desc bool) *wiqlQuery {
+	if desc {
+		q.orderBy = append(q.orderBy, field+" Desc")
+	} else {
+		q.orderBy = append(q.orderBy, field+" Asc")
+	}
+	return q
+}
+
+func (q *wiqlQuery) String() string {
+	var sb strings.Builder
+	sb.WriteString("Select " + strings.Join(q.fields, ", ") + " From WorkItems")
+	if len(q.conditions) > 0 {
+		sb.WriteString(" Where " + strings.Join(q.conditions, " And "))
+	}
+	if len(q.orderBy) > 0 {
+		sb.WriteString(" Order By " + strings.Join(q.orderBy, ", "))
+	}
+	return sb.String()
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWiqlQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query *wiqlQuery
+		want  string
+	}{
+		{
+			name:  "no conditions",
+			query: newWiqlQuery("[System.Id]"),
+			want:  `Select [System.Id] From WorkItems`,
+		},
+		{
+			name:  "where before order by",
+			query: newWiqlQuery("[System.Id]").order("[System.Id]", false).project().idAfter(10),
+			want:  `Select [System.Id] From WorkItems Where [System.TeamProject] = @project And [System.Id] > 10 Order By [System.Id] Asc`,
+		},
+		{
+			name:  "zero changed date",
+			query: newWiqlQuery("[System.Id]").changedAfter(time.Time{}),
+			want:  `Select [System.Id] From WorkItems`,
+		},
+		{
+			name:  "changed date",
+			query: newWiqlQuery("[System.Id]").changedAfter(time.Date(2020, 3, 4, 5, 6, 7, 0, time.UTC)),
+			want:  `Select [System.Id] From WorkItems Where [System.ChangedDate] > '03/04/2020 05:06:07Z'`,
+		},
+		{
+			name:  "area paths",
+			query: newWiqlQuery("[System.Id]").areaPaths([]string{`Proj\A`, `Proj\B`}, []string{`Proj\A\Old`}),
+			want:  `Select [System.Id] From WorkItems Where ([System.AreaPath] Under 'Proj\A' Or [System.AreaPath] Under 'Proj\B') And [System.AreaPath] Not Under 'Proj\A\Old'`,
+		},
+		{
+			name:  "types",
+			query: newWiqlQuery("[System.Id]").types([]string{"Bug", "Task"}, []string{"Test Case"}),
+			want:  `Select [System.Id] From WorkItems Where [System.WorkItemType] In ('Bug', 'Task') And [System.WorkItemType] Not In ('Test Case')`,
+		},
+		{
+			name:  "quotes escaped",
+			query: newWiqlQuery("[System.Id]").types([]string{"Customer's Bug"}, nil),
+			want:  `Select [System.Id] From WorkItems Where [System.WorkItemType] In ('Customer''s Bug')`,
+		},
+		{
+			name:  "many fields and orders",
+			query: newWiqlQuery("[System.Id]", "[System.Title]").order("[System.ChangedDate]", true).order("[System.Id]", false),
+			want:  `Select [System.Id], [System.Title] From WorkItems Order By [System.ChangedDate] Desc, [System.Id] Asc`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.String(); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package api
+
+import "testing"
+
+func TestAreaPathUnder(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		parents []string
+		want    bool
+	}{
+		{"same path", `Proj\Team`, []string{`Proj\Team`}, true},
+		{"child path", `Proj\Team\Sub`, []string{`Proj\Team`}, true},
+		{"case insensitive", `proj\TEAM\sub`, []string{`Proj\Team`}, true},
+		{"sibling with the same prefix", `Proj\Teams`, []string{`Proj\Team`}, false},
+		{"parent of the path", `Proj`, []string{`Proj\Team`}, false},
+		{"any of the parents", `Proj\Other\Sub`, []string{`Proj\Team`, `Proj\Other`}, true},
+		{"no parents", `Proj\Team`, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := areaPathUnder(tt.path, tt.parents...); got != tt.want {
+				t.Errorf("areaPathUnder(%q, %q) = %v, want %v", tt.path, tt.parents, got, tt.want)
+			}
+		})
+	}
+}
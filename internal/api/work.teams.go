@@ -7,7 +7,8 @@ import (
 	"github.com/pinpt/agent/v4/sdk"
 )
 
-func (a *API) FetchTeams(projid string) ([]string, error) {
+// FetchTeams returns the team ids of the project, and their names by id
+func (a *API) FetchTeams(projid string) ([]string, map[string]string, error) {
 
 	sdk.LogInfo(a.logger, "fetching teams", "project_id", projid)
 
@@ -17,11 +18,13 @@ func (a *API) FetchTeams(projid string) ([]string, error) {
 	}
 	_, err := a.get(endpoint, nil, &out)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	var res []string
+	names := map[string]string{}
 	for _, team := range out.Value {
 		res = append(res, team.ID)
+		names[team.ID] = team.Name
 	}
-	return res, nil
+	return res, names, nil
 }
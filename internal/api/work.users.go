@@ -20,6 +20,9 @@ func (a *API) FetchUsers(projid string, teamids []string, workUsermap map[string
 		return err
 	}
 	for _, u := range rawusers {
+		if err := a.setUserEmail(u); err != nil {
+			return err
+		}
 		workUsermap[u.ID] = &sdk.WorkUser{
 			AvatarURL:             sdk.StringPointer(u.ImageURL),
 			CustomerID:            a.customerID,
@@ -68,15 +71,33 @@ func (a *API) fetchAllUsers(projid string, teamids []string) ([]usersResponse, e
 func (a *API) fetchUsers(projid string, teamid string) ([]usersResponse, error) {
 
 	endpoint := fmt.Sprintf(`_apis/projects/%s/teams/%s/members`, url.PathEscape(projid), url.PathEscape(teamid))
-	var out struct {
-		Value []usersResponseAzure `json:"value"`
-	}
-	if _, err := a.get(endpoint, nil, &out); err != nil {
-		return nil, err
-	}
 	var users []usersResponse
-	for _, r := range out.Value {
-		users = append(users, r.Identity)
+
+	out := make(chan objects)
+	errochan := make(chan error)
+	go func() {
+		for object := range out {
+			var value []usersResponseAzure
+			if err := object.Unmarshal(&value); err != nil {
+				errochan <- err
+				return
+			}
+			for _, r := range value {
+				users = append(users, r.Identity)
+			}
+		}
+		errochan <- nil
+	}()
+	// ===========================================
+	go func() {
+		// the team members api has no continuation token, only $top and $skip
+		err := a.paginate(endpoint, paginationTypeSkip, nil, out)
+		if err != nil {
+			errochan <- err
+		}
+	}()
+	if err := <-errochan; err != nil {
+		return nil, err
 	}
 	return users, nil
 }
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"errors"
+	"strings"
 
 	"github.com/pinpt/agent/v4/sdk"
 	"github.com/pinpt/azure/internal/api"
@@ -73,6 +74,35 @@ func (g *AzureIntegration) getHTTPCredOpts(config sdk.Config) (string, sdk.WithH
 	return "", nil, errors.New("missing auth")
 }
 
+// configStrings reads a comma separated list from the config, it returns nil if the key isn't set
+func configStrings(config sdk.Config, key string) []string {
+	ok, val := config.GetString(key)
+	if !ok {
+		return nil
+	}
+	res := []string{}
+	for _, v := range strings.Split(val, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func (g *AzureIntegration) apiOptions(config sdk.Config) api.Options {
+	options := api.Options{
+		IncludeAreaPaths:  configStrings(config, "include_area_paths"),
+		ExcludeAreaPaths:  configStrings(config, "exclude_area_paths"),
+		IncludeIssueTypes: configStrings(config, "include_issue_types"),
+		// nil if not set, so the default types are excluded
+		ExcludeIssueTypes: configStrings(config, "exclude_issue_types"),
+		CustomFields:      configStrings(config, "custom_fields"),
+	}
+	_, options.HistoryAttachments = config.GetBool("include_attachment_history")
+	_, options.AllBranchCommits = config.GetBool("commits_all_branches")
+	return options
+}
+
 func (g *AzureIntegration) fetchAccounts(customerID, integrationID string, config sdk.Config) (*sdk.Config, error) {
 	url, creds, err := g.getHTTPCredOpts(config)
 	if err != nil {
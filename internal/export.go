@@ -33,6 +33,7 @@ func (g *AzureIntegration) Export(export sdk.Export) error {
 	workUsermap := map[string]*sdk.WorkUser{}
 	sourcecodeUsermap := map[string]*sdk.SourceCodeUser{}
 	a := api.New(g.logger, client, state, pipe, customerID, integrationID, g.refType, concurr, creds)
+	a.SetOptions(g.apiOptions(config))
 	if err := a.FetchStatuses(); err != nil {
 		return err
 	}
@@ -52,18 +53,8 @@ func (g *AzureIntegration) Export(export sdk.Export) error {
 		if ok, _ := state.Get("updated_"+proj.RefID, &strTime); ok {
 			updated, _ = time.Parse(time.RFC3339Nano, strTime)
 		}
-		repos, err := a.FetchRepos(proj.RefID)
-		if err != nil {
-			return fmt.Errorf("error fetching repos. err: %v", err)
-		}
-		for _, r := range repos {
-			pipe.Write(r)
-			if err := a.FetchPullRequests(proj.RefID, r.RefID, r.Name, updated); err != nil {
-				return fmt.Errorf("error fetching pull requests repos. err: %v", err)
-			}
-		}
-
-		ids, err := a.FetchTeams(proj.RefID)
+		// users first, the commit authors are matched to them by email
+		ids, teams, err := a.FetchTeams(proj.RefID)
 		if err != nil {
 			return fmt.Errorf("error fetching teams. err: %v", err)
 		}
@@ -73,9 +64,29 @@ func (g *AzureIntegration) Export(export sdk.Export) error {
 		if err := a.FetchSprints(proj.RefID, ids); err != nil {
 			return fmt.Errorf("error fetching sprints. err: %v", err)
 		}
+		if err := a.FetchBoards(proj.RefID, teams); err != nil {
+			return fmt.Errorf("error fetching boards. err: %v", err)
+		}
+		// issues before the pull requests so that the prs linked from an issue get its id
 		if err := a.FetchAllIssues(proj.RefID, updated); err != nil {
 			return fmt.Errorf("error fetching issues. err: %v", err)
 		}
+		repos, err := a.FetchRepos(proj.RefID)
+		if err != nil {
+			return fmt.Errorf("error fetching repos. err: %v", err)
+		}
+		for _, r := range repos {
+			pipe.Write(r)
+			if err := a.FetchPullRequests(proj.RefID, r.RefID, r.Name, updated); err != nil {
+				return fmt.Errorf("error fetching pull requests repos. err: %v", err)
+			}
+			if err := a.FetchCommits(proj.RefID, r.RefID, r.DefaultBranch, updated); err != nil {
+				return fmt.Errorf("error fetching commits. err: %v", err)
+			}
+			if err := a.FetchBranches(proj.RefID, r.RefID, r.DefaultBranch); err != nil {
+				return fmt.Errorf("error fetching branches. err: %v", err)
+			}
+		}
 		state.Set("updated_"+proj.RefID, time.Now().Format(time.RFC3339Nano))
 	}
 	async := sdk.NewAsync(2)
@@ -104,16 +115,16 @@ func (g *AzureIntegration) Export(export sdk.Export) error {
 
 func (g *AzureIntegration) sendCapabilities(pipe sdk.Pipe, customerID, integrationID, projid string) {
 	pipe.Write(&sdk.WorkProjectCapability{
-		Attachments:           false,
+		Attachments:           true,
 		ChangeLogs:            true,
 		CustomerID:            customerID,
 		DueDates:              true,
-		Epics:                 false,
+		Epics:                 true,
 		InProgressStates:      true,
 		IntegrationInstanceID: &integrationID,
-		KanbanBoards:          false,
-		LinkedIssues:          false,
-		Parents:               false,
+		KanbanBoards:          true,
+		LinkedIssues:          true,
+		Parents:               true,
 		Priorities:            true,
 		ProjectID:             sdk.NewWorkProjectID(customerID, projid, g.refType),
 		RefID:                 projid,
@@ -56,6 +56,7 @@ func (g *AzureIntegration) WebHook(webhook sdk.WebHook) error {
 	rawPayload := webhook.Bytes()
 
 	a := api.New(g.logger, client, webhook.State(), webhook.Pipe(), customerID, integrationID, g.refType, concurr, creds)
+	a.SetOptions(g.apiOptions(config))
 
 	if strings.HasPrefix(payload.EventType, "workitem.") {
 		return g.handleWorkWebHooks(customerID, webhook.IntegrationInstanceID(), payload.EventType, rawPayload, pipe, a)
@@ -72,7 +73,7 @@ func (g *AzureIntegration) handleSourceCodeWebHooks(eventType string, rawPayload
 			[]api.PullRequestResponse{data.Resource},
 			data.Resource.Repository.Project.ID,
 			data.Resource.Repository.ID,
-			data.Resource.Repository.Name, time.Time{},
+			data.Resource.Repository.Name,
 		)
 
 	}